@@ -0,0 +1,219 @@
+package conf
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// WithEnvPrefix sets a prefix (i.e. "MYAPP_") for the env var names
+// of all config fields, including those set with an `env` tag.
+func WithEnvPrefix(prefix string) LoaderOpt {
+	return func(opts *loaderOptions) {
+		opts.envPrefix = prefix
+	}
+}
+
+// WithAutoEnv derives env var names for fields without an `env` tag
+// from the field names, with the names of any parent structs as a prefix.
+// For example, with [WithEnvPrefix]("MYAPP_"):
+//
+//	type Config struct {
+//		BaseURL string           // MYAPP_BASE_URL
+//		Server  struct {
+//			Port int             // MYAPP_SERVER_PORT
+//		}
+//		Tags   []string          // MYAPP_TAGS=a,b
+//		Labels map[string]string // MYAPP_LABELS=k1:v1,k2:v2
+//	}
+//
+// An `envPrefix` tag on a nested struct replaces its derived prefix.
+// Slices and maps are parsed from delimited strings, using the
+// `envSeparator` (default ",") and `envKeyValSeparator` (default ":") tags.
+func WithAutoEnv() LoaderOpt {
+	return func(opts *loaderOptions) {
+		opts.autoEnv = true
+	}
+}
+
+// EnvVar describes an env var recognized by the loader.
+type EnvVar struct {
+	// Name is the env var name (i.e. "MYAPP_BASE_URL").
+	Name string
+	// Key is the dot separated config key it sets (empty for the profile env var).
+	Key string
+	// Type is the value type (i.e. "duration").
+	Type string
+	// Usage is the field description (from the `usage` tag).
+	Usage string
+}
+
+// EnvVars returns each of the env vars recognized by the loader,
+// in struct field order (i.e. for help output).
+func (l *Loader[C]) EnvVars() []EnvVar {
+	vars := []EnvVar{}
+	for _, f := range l.envFields(reflect.ValueOf(l.newConfig())) {
+		if f.EnvVar == "" {
+			continue
+		}
+		vars = append(vars, EnvVar{
+			Name:  f.EnvVar,
+			Key:   f.Key,
+			Type:  flagTypeName(f.Field.Type),
+			Usage: f.Field.Tag.Get("usage"),
+		})
+	}
+	if l.opts.profiles && l.opts.profileEnv != "" {
+		vars = append(vars, EnvVar{
+			Name:  l.opts.profileEnv,
+			Type:  "string",
+			Usage: "Config profile to use",
+		})
+	}
+	return vars
+}
+
+// envScope returns the env var naming scope for the config struct.
+func (l *Loader[C]) envScope() envScope {
+	return envScope{prefix: l.opts.envPrefix, auto: l.opts.autoEnv, autoPrefix: l.opts.envPrefix}
+}
+
+// envFields returns the leaf fields of the struct v,
+// with env var names resolved using the loader env options.
+func (l *Loader[C]) envFields(v reflect.Value) []configField {
+	return envConfigFields(v, l.envScope())
+}
+
+// applyAutoEnv sets the fields with derived env var names
+// (those with an `env` tag are set by env.Parse).
+func (l *Loader[C]) applyAutoEnv(config C) error {
+	if !l.opts.autoEnv {
+		return nil
+	}
+	for _, f := range l.envFields(reflect.ValueOf(config)) {
+		if f.EnvVar == "" || envTagName(f.Field) != "" {
+			continue
+		}
+		s, ok := os.LookupEnv(f.EnvVar)
+		if !ok {
+			continue
+		}
+		v, err := parseEnvValue(f.Field, s)
+		if err != nil {
+			return fmt.Errorf("invalid value for env var %s: %w", f.EnvVar, err)
+		}
+		f.Value.Set(v)
+	}
+	return nil
+}
+
+// parseEnvValue parses the env var value s for the struct field.
+// Slices and maps use the same delimiters as env.Parse.
+func parseEnvValue(sf reflect.StructField, s string) (reflect.Value, error) {
+	t := sf.Type
+	sep := sf.Tag.Get("envSeparator")
+	if sep == "" {
+		sep = ","
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		out := reflect.MakeSlice(t, 0, 0)
+		if s == "" {
+			return out, nil
+		}
+		for _, item := range strings.Split(s, sep) {
+			elem, err := parseFlagValue(t.Elem(), item)
+			if err != nil {
+				return out, err
+			}
+			out = reflect.Append(out, elem)
+		}
+		return out, nil
+	case reflect.Map:
+		kvSep := sf.Tag.Get("envKeyValSeparator")
+		if kvSep == "" {
+			kvSep = ":"
+		}
+		out := reflect.MakeMap(t)
+		if s == "" {
+			return out, nil
+		}
+		for _, pair := range strings.Split(s, sep) {
+			k, item, ok := strings.Cut(pair, kvSep)
+			if !ok {
+				return out, fmt.Errorf("expected key%svalue: %s", kvSep, pair)
+			}
+			key, err := parseFlagValue(t.Key(), k)
+			if err != nil {
+				return out, err
+			}
+			elem, err := parseFlagValue(t.Elem(), item)
+			if err != nil {
+				return out, err
+			}
+			out.SetMapIndex(key, elem)
+		}
+		return out, nil
+	default:
+		return parseFlagValue(t, s)
+	}
+}
+
+// envScope determines the env var names of the fields in a (nested) struct.
+type envScope struct {
+	// prefix is the loader prefix plus any `envPrefix` tags
+	// (the prefix env.Parse uses for `env` tags).
+	prefix string
+	// auto is true if names are derived for fields without an `env` tag.
+	auto bool
+	// autoPrefix is the prefix for derived names.
+	autoPrefix string
+}
+
+// nested returns the scope for the nested struct field sf.
+func (s envScope) nested(sf reflect.StructField) envScope {
+	tag := sf.Tag.Get("envPrefix")
+	nested := envScope{prefix: s.prefix + tag, auto: s.auto}
+	if tag != "" {
+		nested.autoPrefix = s.autoPrefix + tag
+	} else {
+		nested.autoPrefix = s.autoPrefix + envName(sf.Name) + "_"
+	}
+	return nested
+}
+
+// name returns the env var name for the field sf (if any).
+func (s envScope) name(sf reflect.StructField) string {
+	if name := envTagName(sf); name != "" {
+		return s.prefix + name
+	}
+	if s.auto {
+		return s.autoPrefix + envName(sf.Name)
+	}
+	return ""
+}
+
+// envTagName returns the name in the `env` tag of the field (if any).
+func envTagName(sf reflect.StructField) string {
+	return strings.Split(sf.Tag.Get("env"), ",")[0]
+}
+
+// envName converts a Go field name to an env var name
+// (i.e. "BaseURL" to "BASE_URL").
+func envName(name string) string {
+	runes := []rune(name)
+	out := []rune{}
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				out = append(out, '_')
+			}
+		}
+		out = append(out, unicode.ToUpper(r))
+	}
+	return string(out)
+}
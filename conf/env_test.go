@@ -0,0 +1,137 @@
+package conf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type EnvConfig struct {
+	BaseURL string        `yaml:"base_url" usage:"API URL"`
+	Debug   bool          `yaml:"debug" env:"DEBUG"`
+	Timeout time.Duration `yaml:"timeout" default:"5s"`
+	Server  struct {
+		Port    int `yaml:"port" default:"8080"`
+		TLSCert string
+	} `yaml:"server"`
+	Database struct {
+		URL string `yaml:"url"`
+	} `yaml:"database" envPrefix:"DB_"`
+	Tags   []string          `yaml:"tags"`
+	Ports  []int             `yaml:"ports" envSeparator:";"`
+	Labels map[string]string `yaml:"labels"`
+	Token  Secret            `yaml:"token"`
+}
+
+func TestLoader_AutoEnv(t *testing.T) {
+	t.Setenv("MYAPP_BASE_URL", "https://example.com")
+	t.Setenv("MYAPP_DEBUG", "true")
+	t.Setenv("MYAPP_TIMEOUT", "1m")
+	t.Setenv("MYAPP_SERVER_PORT", "9000")
+	t.Setenv("MYAPP_SERVER_TLS_CERT", "cert.pem")
+	t.Setenv("MYAPP_DB_URL", "postgres://localhost/db")
+	t.Setenv("MYAPP_TAGS", "a,b")
+	t.Setenv("MYAPP_PORTS", "1;2")
+	t.Setenv("MYAPP_LABELS", "k1:v1,k2:v2")
+	t.Setenv("MYAPP_TOKEN", "env:MYAPP_SECRET")
+	t.Setenv("MYAPP_SECRET", "s3cret")
+
+	loader := NewLoader(&EnvConfig{}, "", WithEnvPrefix("MYAPP_"), WithAutoEnv())
+	config, err := loader.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com", config.BaseURL)
+	assert.Equal(t, true, config.Debug)
+	assert.Equal(t, time.Minute, config.Timeout)
+	assert.Equal(t, 9000, config.Server.Port)
+	assert.Equal(t, "cert.pem", config.Server.TLSCert)
+	assert.Equal(t, "postgres://localhost/db", config.Database.URL)
+	assert.Equal(t, []string{"a", "b"}, config.Tags)
+	assert.Equal(t, []int{1, 2}, config.Ports)
+	assert.Equal(t, map[string]string{"k1": "v1", "k2": "v2"}, config.Labels)
+	token, err := config.Token.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", token)
+
+	origin, _ := loader.Origin("server.port")
+	assert.Equal(t, "env:MYAPP_SERVER_PORT", origin.String())
+	origin, _ = loader.Origin("debug")
+	assert.Equal(t, "env:MYAPP_DEBUG", origin.String())
+}
+
+func TestLoader_AutoEnv_WhenInvalid(t *testing.T) {
+	t.Setenv("SERVER_PORT", "nope")
+	loader := NewLoader(&EnvConfig{}, "", WithAutoEnv())
+	_, err := loader.Load()
+	assert.EqualError(t, err, `invalid value for env var SERVER_PORT: unable to parse "nope" as int`)
+
+	t.Setenv("SERVER_PORT", "")
+	t.Setenv("LABELS", "k1=v1")
+	_, err = loader.Reload()
+	assert.EqualError(t, err, "invalid value for env var LABELS: expected key:value: k1=v1")
+}
+
+func TestLoader_EnvPrefix(t *testing.T) {
+	t.Setenv("DEBUG", "true")
+	t.Setenv("SERVER_PORT", "9000")
+
+	// Only tagged fields are set without auto env.
+	loader := NewLoader(&EnvConfig{}, "", WithEnvPrefix("MYAPP_"))
+	config, err := loader.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, false, config.Debug)
+	assert.Equal(t, 8080, config.Server.Port)
+
+	t.Setenv("MYAPP_DEBUG", "true")
+	config, err = loader.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, true, config.Debug)
+}
+
+func TestLoader_EnvPrefix_Strict(t *testing.T) {
+	t.Setenv("MYAPP_SERVER_PROT", "9000")
+	loader := NewLoader(&EnvConfig{}, "", WithEnvPrefix("MYAPP_"), WithAutoEnv(), WithStrict())
+	_, err := loader.Load()
+	assert.EqualError(t, err, "unknown env var MYAPP_SERVER_PROT (did you mean MYAPP_SERVER_PORT?)")
+}
+
+func TestLoader_EnvVars(t *testing.T) {
+	loader := NewLoader(&EnvConfig{}, "", WithEnvPrefix("MYAPP_"), WithProfiles("MYAPP_PROFILE"))
+	assert.Equal(t, []EnvVar{
+		{Name: "MYAPP_DEBUG", Key: "debug", Type: "bool"},
+		{Name: "MYAPP_PROFILE", Type: "string", Usage: "Config profile to use"},
+	}, loader.EnvVars())
+
+	loader = NewLoader(&EnvConfig{}, "", WithEnvPrefix("MYAPP_"), WithAutoEnv())
+	assert.Equal(t, []EnvVar{
+		{Name: "MYAPP_BASE_URL", Key: "base_url", Type: "string", Usage: "API URL"},
+		{Name: "MYAPP_DEBUG", Key: "debug", Type: "bool"},
+		{Name: "MYAPP_TIMEOUT", Key: "timeout", Type: "duration"},
+		{Name: "MYAPP_SERVER_PORT", Key: "server.port", Type: "int"},
+		{Name: "MYAPP_SERVER_TLS_CERT", Key: "server.tlscert", Type: "string"},
+		{Name: "MYAPP_DB_URL", Key: "database.url", Type: "string"},
+		{Name: "MYAPP_TAGS", Key: "tags", Type: "strings"},
+		{Name: "MYAPP_PORTS", Key: "ports", Type: "ints"},
+		{Name: "MYAPP_LABELS", Key: "labels", Type: "stringToString"},
+		{Name: "MYAPP_TOKEN", Key: "token", Type: "string"},
+	}, loader.EnvVars())
+}
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Port", "PORT"},
+		{"BaseURL", "BASE_URL"},
+		{"APIKey", "API_KEY"},
+		{"HTTPServer", "HTTP_SERVER"},
+		{"MaxIdleConns", "MAX_IDLE_CONNS"},
+		{"Retry2Count", "RETRY2_COUNT"},
+		{"OAuth2Token", "O_AUTH2_TOKEN"},
+		{"Base_URL", "BASE_URL"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, envName(tt.name), tt.name)
+	}
+}
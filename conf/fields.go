@@ -0,0 +1,194 @@
+package conf
+
+import (
+	"encoding"
+	"reflect"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+	yamlUnmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+)
+
+// configField is a leaf field in a config struct.
+type configField struct {
+	// Key is the dot separated path of YAML keys (i.e. "database.url").
+	Key string
+	// EnvVar is the name of the env var for the field (if any).
+	EnvVar string
+	// Flag is the name of the command-line flag for the field (if any).
+	Flag  string
+	Field reflect.StructField
+	Value reflect.Value
+}
+
+// configFields returns the leaf fields of the struct v (or pointer to one).
+// Nested structs are traversed; maps, slices, and scalars are leaves.
+// Env var names are those of the `env` tags (see [Loader.envFields]).
+func configFields(v reflect.Value) []configField {
+	return envConfigFields(v, envScope{})
+}
+
+// envConfigFields returns the leaf fields of the struct v,
+// with env var names resolved in the scope.
+func envConfigFields(v reflect.Value, scope envScope) []configField {
+	fields := []configField{}
+	walkFields(v, "", scope, &fields)
+	return fields
+}
+
+func walkFields(v reflect.Value, keyPrefix string, scope envScope, fields *[]configField) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() && !sf.Anonymous {
+			continue
+		}
+		name, inline, skip := yamlFieldName(sf)
+		if skip {
+			continue
+		}
+		fv := v.Field(i)
+		key := keyPrefix + name
+		if inline {
+			walkFields(fv, keyPrefix, scope, fields)
+			continue
+		}
+		if isBranch(sf.Type) {
+			walkFields(fv, key+".", scope.nested(sf), fields)
+			continue
+		}
+		*fields = append(*fields, configField{
+			Key:    key,
+			EnvVar: scope.name(sf),
+			Flag:   sf.Tag.Get("flag"),
+			Field:  sf,
+			Value:  fv,
+		})
+	}
+}
+
+// fileKeys returns the keys set in the YAML data, resolved against
+// the config struct type t. Keys of leaf values (i.e. maps) are not
+// descended into. Returns nil if data is not valid YAML.
+func fileKeys(data []byte, t reflect.Type) []string {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(data, doc); err != nil || len(doc.Content) == 0 {
+		return nil
+	}
+	keys := []string{}
+	walkNode(doc.Content[0], t, "", func(key string, node *yaml.Node, sf *reflect.StructField) {
+		keys = append(keys, key)
+	})
+	return keys
+}
+
+// nodeFunc is called by [walkNode] for each leaf key.
+type nodeFunc func(key string, node *yaml.Node, sf *reflect.StructField)
+
+// walkNode calls fn for each leaf key in the mapping node,
+// resolved against the struct type t.
+// The struct field is nil when the key does not match any field.
+func walkNode(node *yaml.Node, t reflect.Type, prefix string, fn nodeFunc) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if node.Kind != yaml.MappingNode || t.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		key := prefix + keyNode.Value
+		sf, ok := structFieldForKey(t, keyNode.Value)
+		if ok && isBranch(sf.Type) && valueNode.Kind == yaml.MappingNode {
+			walkNode(valueNode, sf.Type, key+".", fn)
+			continue
+		}
+		if ok {
+			fn(key, keyNode, &sf)
+		} else {
+			fn(key, keyNode, nil)
+		}
+	}
+}
+
+// structFieldForKey returns the field in struct type t for the YAML key.
+func structFieldForKey(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() && !sf.Anonymous {
+			continue
+		}
+		name, inline, skip := yamlFieldName(sf)
+		if skip {
+			continue
+		}
+		if inline {
+			ft := sf.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if f, ok := structFieldForKey(ft, key); ok {
+					return f, true
+				}
+			}
+			if ft.Kind() == reflect.Map {
+				// Inline maps hold any remaining keys.
+				return sf, true
+			}
+			continue
+		}
+		if name == key {
+			return sf, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// yamlFieldName returns the YAML key for the struct field
+// using the same rules as [yaml.Marshal].
+func yamlFieldName(sf reflect.StructField) (name string, inline bool, skip bool) {
+	tag := sf.Tag.Get("yaml")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	for _, flag := range parts[1:] {
+		if flag == "inline" {
+			inline = true
+		}
+	}
+	name = parts[0]
+	if name == "" {
+		name = strings.ToLower(sf.Name)
+	}
+	return name, inline, false
+}
+
+// isBranch returns true if t is a struct that should be traversed
+// rather than treated as a single value.
+func isBranch(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return false
+	}
+	pt := reflect.PointerTo(t)
+	return !pt.Implements(yamlUnmarshalerType) && !pt.Implements(textUnmarshalerType)
+}
@@ -0,0 +1,53 @@
+package conf
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fieldsEmbedded struct {
+	Inlined string `yaml:"inlined"`
+}
+
+type fieldsConfig struct {
+	fieldsEmbedded `yaml:",inline"`
+
+	Name    string `yaml:"name" env:"NAME"`
+	Skipped string `yaml:"-"`
+	Created time.Time
+	Server  *struct {
+		Host string `env:"HOST"`
+	} `envPrefix:"SERVER_"`
+	hidden string //nolint:unused
+}
+
+func TestConfigFields(t *testing.T) {
+	config := &fieldsConfig{}
+	config.Server = &struct {
+		Host string `env:"HOST"`
+	}{}
+
+	keys := []string{}
+	envVars := []string{}
+	for _, f := range configFields(reflect.ValueOf(config)) {
+		keys = append(keys, f.Key)
+		envVars = append(envVars, f.EnvVar)
+	}
+	assert.Equal(t, []string{"inlined", "name", "created", "server.host"}, keys)
+	assert.Equal(t, []string{"", "NAME", "", "SERVER_HOST"}, envVars)
+
+	// Nil pointers are not traversed.
+	config.Server = nil
+	assert.Len(t, configFields(reflect.ValueOf(config)), 3)
+}
+
+func TestFileKeys(t *testing.T) {
+	data := []byte("inlined: a\nname: b\nunknown: c\nserver:\n  host: d\ncreated: 2023-01-01T00:00:00Z\n")
+	keys := fileKeys(data, reflect.TypeOf(&fieldsConfig{}))
+	assert.Equal(t, []string{"inlined", "name", "unknown", "server.host", "created"}, keys)
+
+	assert.Nil(t, fileKeys([]byte("{nope"), reflect.TypeOf(&fieldsConfig{})))
+}
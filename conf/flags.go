@@ -0,0 +1,254 @@
+package conf
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// BindFlags registers a flag in fs for each config field with a `flag` tag.
+// The flag usage is taken from the `usage` tag, and the default value
+// (from the `default` tag) is shown in the help text:
+//
+//	type Config struct {
+//		BaseURL string `flag:"base-url" usage:"API base URL" default:"https://example.com"`
+//		Tags    []string `flag:"tag" usage:"Tags to apply (repeatable)"`
+//	}
+//
+// Flags that are set on the command line take precedence over all other
+// layers, including env vars. The config must be loaded (or reloaded)
+// after the flags are parsed.
+//
+// Slice flags may be repeated or given comma separated values, and map flags
+// take comma separated "key=value" pairs. The flag values also implement
+// the pflag.Value interface, so fs can be added to a pflag.FlagSet
+// via AddGoFlagSet.
+//
+// When profiles are enabled (see [WithProfiles]), a [ProfileFlag] flag
+// is also registered to select the profile.
+func (l *Loader[C]) BindFlags(fs *flag.FlagSet) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	defaults := l.newConfig()
+	if err := defaultsSet(defaults); err != nil {
+		return err
+	}
+	for _, f := range configFields(reflect.ValueOf(defaults)) {
+		if f.Flag == "" {
+			continue
+		}
+		if fs.Lookup(f.Flag) != nil {
+			return fmt.Errorf("flag redefined: %s", f.Flag)
+		}
+		fv := &flagValue{
+			name: f.Flag,
+			key:  f.Key,
+			typ:  f.Field.Type,
+		}
+		if !f.Value.IsZero() {
+			fv.def = formatFlagValue(f.Value)
+		}
+		fs.Var(fv, f.Flag, f.Field.Tag.Get("usage"))
+		l.flags = append(l.flags, fv)
+	}
+	if l.opts.profiles {
+		return l.bindProfileFlag(fs)
+	}
+	return nil
+}
+
+// applyFlags sets the value of each changed flag in the config struct.
+func applyFlags(config any, flags []*flagValue) error {
+	fields := map[string]reflect.Value{}
+	for _, f := range configFields(reflect.ValueOf(config)) {
+		fields[f.Key] = f.Value
+	}
+	for _, fv := range flags {
+		field, ok := fields[fv.key]
+		raw := fv.values()
+		if len(raw) == 0 || !ok {
+			continue
+		}
+		v, err := fv.parse(raw)
+		if err != nil {
+			return fmt.Errorf("invalid value for flag -%s: %w", fv.name, err)
+		}
+		field.Set(v)
+	}
+	return nil
+}
+
+// flagValue is a flag bound to a config field.
+// It implements both [flag.Value] and pflag.Value.
+type flagValue struct {
+	name string
+	key  string
+	typ  reflect.Type
+	def  string
+
+	// mu guards raw, since flags may be set while the
+	// config is being reloaded (see [Loader.Watch]).
+	mu sync.Mutex
+	// raw holds the values set on the command line.
+	raw []string
+}
+
+// String returns the value set on the command line, or the default.
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+	raw := v.values()
+	if len(raw) == 0 {
+		return v.def
+	}
+	if v.typ.Kind() == reflect.Slice || v.typ.Kind() == reflect.Map {
+		return strings.Join(raw, ",")
+	}
+	return raw[len(raw)-1]
+}
+
+// Set parses s and records it as the flag value.
+// Slice and map values are appended to (so the flag may be repeated).
+func (v *flagValue) Set(s string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	raw := []string{s}
+	if v.typ.Kind() == reflect.Slice || v.typ.Kind() == reflect.Map {
+		raw = append(slices.Clone(v.raw), s)
+	}
+	if _, err := v.parse(raw); err != nil {
+		return err
+	}
+	v.raw = raw
+	return nil
+}
+
+// Type returns the name of the flag value type (i.e. "duration").
+// Used by pflag in help text.
+func (v *flagValue) Type() string {
+	return flagTypeName(v.typ)
+}
+
+// IsBoolFlag allows bool flags to be set without a value (i.e. "-debug").
+func (v *flagValue) IsBoolFlag() bool {
+	return v.typ.Kind() == reflect.Bool
+}
+
+func (v *flagValue) changed() bool {
+	return len(v.values()) > 0
+}
+
+// values returns a copy of the values set on the command line.
+func (v *flagValue) values() []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return slices.Clone(v.raw)
+}
+
+// parse returns the typed value for the command line values in raw.
+func (v *flagValue) parse(raw []string) (reflect.Value, error) {
+	switch v.typ.Kind() {
+	case reflect.Slice:
+		out := reflect.MakeSlice(v.typ, 0, len(raw))
+		for _, s := range raw {
+			for _, item := range strings.Split(s, ",") {
+				elem, err := parseFlagValue(v.typ.Elem(), item)
+				if err != nil {
+					return out, err
+				}
+				out = reflect.Append(out, elem)
+			}
+		}
+		return out, nil
+	case reflect.Map:
+		out := reflect.MakeMap(v.typ)
+		for _, s := range raw {
+			for _, pair := range strings.Split(s, ",") {
+				k, item, ok := strings.Cut(pair, "=")
+				if !ok {
+					return out, fmt.Errorf("expected key=value: %s", pair)
+				}
+				key, err := parseFlagValue(v.typ.Key(), k)
+				if err != nil {
+					return out, err
+				}
+				elem, err := parseFlagValue(v.typ.Elem(), item)
+				if err != nil {
+					return out, err
+				}
+				out.SetMapIndex(key, elem)
+			}
+		}
+		return out, nil
+	default:
+		if len(raw) == 0 {
+			return parseFlagValue(v.typ, v.def)
+		}
+		return parseFlagValue(v.typ, raw[len(raw)-1])
+	}
+}
+
+// parseFlagValue parses s as a value of type t.
+// Strings are used as is; all other types are parsed as YAML
+// (the same as values in a config file).
+func parseFlagValue(t reflect.Type, s string) (reflect.Value, error) {
+	ptr := reflect.New(t)
+	if t.Kind() == reflect.String {
+		ptr.Elem().SetString(s)
+		return ptr.Elem(), nil
+	}
+	if err := yaml.Unmarshal([]byte(s), ptr.Interface()); err != nil {
+		return ptr.Elem(), fmt.Errorf("unable to parse %q as %s", s, flagTypeName(t))
+	}
+	return ptr.Elem(), nil
+}
+
+// formatFlagValue formats v in the syntax accepted by [flagValue.Set].
+func formatFlagValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Slice:
+		items := []string{}
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, fmt.Sprint(v.Index(i).Interface()))
+		}
+		return strings.Join(items, ",")
+	case reflect.Map:
+		items := []string{}
+		iter := v.MapRange()
+		for iter.Next() {
+			items = append(items, fmt.Sprintf("%v=%v", iter.Key().Interface(), iter.Value().Interface()))
+		}
+		sort.Strings(items)
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// flagTypeName returns the pflag style name for type t.
+func flagTypeName(t reflect.Type) string {
+	switch {
+	case t == durationType:
+		return "duration"
+	case reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return "string"
+	case t.Kind() == reflect.Slice:
+		return flagTypeName(t.Elem()) + "s"
+	case t.Kind() == reflect.Map:
+		elem := flagTypeName(t.Elem())
+		return flagTypeName(t.Key()) + "To" + strings.ToUpper(elem[:1]) + elem[1:]
+	default:
+		return t.Kind().String()
+	}
+}
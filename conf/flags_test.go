@@ -0,0 +1,162 @@
+package conf
+
+import (
+	"bytes"
+	"flag"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type FlagConfig struct {
+	BaseURL string            `yaml:"base_url" flag:"base-url" usage:"API URL" default:"https://a.io" env:"FLAG_URL"`
+	Debug   bool              `flag:"debug" usage:"Enable debug mode"`
+	Timeout time.Duration     `flag:"timeout" usage:"Request timeout" default:"5s"`
+	Tags    []string          `flag:"tag" usage:"Tags to apply"`
+	Labels  map[string]string `flag:"label" usage:"Labels to apply"`
+	Retries int               `flag:"retries" usage:"Max retries" validate:"lt=10"`
+	Name    string
+	Server  struct {
+		Port int `flag:"port" usage:"Server port" default:"8080"`
+	}
+}
+
+func TestLoader_BindFlags(t *testing.T) {
+	path := writeFixture(t, "config.yaml", "base_url: https://file.example.com\nretries: 3\n")
+	t.Setenv("FLAG_URL", "https://env.example.com")
+
+	loader := NewLoader(&FlagConfig{}, path)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	assert.NoError(t, loader.BindFlags(fs))
+	assert.Nil(t, fs.Lookup("name"))
+
+	err := fs.Parse([]string{
+		"-base-url", "https://flag.example.com",
+		"-debug",
+		"-timeout", "1m",
+		"-tag", "a,b", "-tag", "c",
+		"-label", "team=core,env=dev",
+		"-port", "9090",
+	})
+	assert.NoError(t, err)
+
+	config, err := loader.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "https://flag.example.com", config.BaseURL)
+	assert.Equal(t, true, config.Debug)
+	assert.Equal(t, time.Minute, config.Timeout)
+	assert.Equal(t, []string{"a", "b", "c"}, config.Tags)
+	assert.Equal(t, map[string]string{"team": "core", "env": "dev"}, config.Labels)
+	assert.Equal(t, 3, config.Retries)
+	assert.Equal(t, 9090, config.Server.Port)
+
+	origin, _ := loader.Origin("base_url")
+	assert.Equal(t, Origin{Layer: LayerFlags, Path: "base-url"}, origin)
+	origin, _ = loader.Origin("server.port")
+	assert.Equal(t, Origin{Layer: LayerFlags, Path: "port"}, origin)
+	origin, _ = loader.Origin("retries")
+	assert.Equal(t, LayerUser, origin.Layer)
+}
+
+func TestLoader_BindFlags_WhenReloading(t *testing.T) {
+	loader := NewLoader(&FlagConfig{}, "")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	assert.NoError(t, loader.BindFlags(fs))
+
+	// Flags may be set while the config is reloaded (run w/ -race to verify).
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 20; j++ {
+			assert.NoError(t, loader.reloadAndSwap())
+		}
+	}()
+	for j := 0; j < 20; j++ {
+		assert.NoError(t, fs.Set("tag", "a"))
+	}
+	wg.Wait()
+
+	config, err := loader.Reload()
+	assert.NoError(t, err)
+	assert.Len(t, config.Tags, 20)
+}
+
+func TestLoader_BindFlags_Help(t *testing.T) {
+	loader := NewLoader(&FlagConfig{}, "")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	assert.NoError(t, loader.BindFlags(fs))
+
+	buf := &bytes.Buffer{}
+	fs.SetOutput(buf)
+	fs.PrintDefaults()
+	assert.Contains(t, buf.String(), "API URL (default https://a.io)")
+	assert.Contains(t, buf.String(), "Request timeout (default 5s)")
+	assert.Contains(t, buf.String(), "Server port (default 8080)")
+	assert.NotContains(t, buf.String(), "Max retries (default")
+	assert.NotContains(t, buf.String(), "Enable debug mode (default")
+}
+
+func TestLoader_BindFlags_Errors(t *testing.T) {
+	loader := NewLoader(&FlagConfig{}, "")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(&bytes.Buffer{})
+	fs.String("debug", "", "")
+	assert.ErrorContains(t, loader.BindFlags(fs), "flag redefined: debug")
+
+	loader = NewLoader(&FlagConfig{}, "")
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(&bytes.Buffer{})
+	assert.NoError(t, loader.BindFlags(fs))
+	err := fs.Parse([]string{"-retries", "abc"})
+	assert.ErrorContains(t, err, `invalid value "abc" for flag -retries: unable to parse "abc" as int`)
+	err = fs.Parse([]string{"-label", "team"})
+	assert.ErrorContains(t, err, "expected key=value: team")
+
+	// Flag values are validated along with the rest of the config.
+	assert.NoError(t, fs.Parse([]string{"-retries", "20"}))
+	_, err = loader.Load()
+	assert.ErrorContains(t, err, "Retries")
+}
+
+func TestLoader_BindFlags_Save(t *testing.T) {
+	path := writeFixture(t, "config.yaml", "retries: 3\n")
+
+	loader := NewLoader(&FlagConfig{}, path)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	assert.NoError(t, loader.BindFlags(fs))
+	assert.NoError(t, fs.Parse([]string{"-debug", "-retries", "5"}))
+	_, err := loader.Load()
+	assert.NoError(t, err)
+
+	// Flag values are not persisted.
+	assert.NoError(t, loader.Save())
+	assert.Equal(t, "retries: 3\n", readFile(t, path))
+}
+
+func TestFlagValue_Type(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{"", "string"},
+		{true, "bool"},
+		{0, "int"},
+		{int64(0), "int64"},
+		{1.5, "float64"},
+		{time.Second, "duration"},
+		{[]string{}, "strings"},
+		{[]int{}, "ints"},
+		{map[string]string{}, "stringToString"},
+		{map[string]int{}, "stringToInt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			fv := &flagValue{typ: reflect.TypeOf(tt.value)}
+			assert.Equal(t, tt.want, fv.Type())
+		})
+	}
+}
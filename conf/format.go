@@ -0,0 +1,227 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Built-in config format names.
+const (
+	FormatYAML  = "yaml"
+	FormatJSON  = "json"
+	FormatJSONC = "jsonc"
+	FormatTOML  = "toml"
+)
+
+var (
+	formatsMu sync.RWMutex
+	formats   = []*Format{
+		{Name: FormatYAML, Extensions: []string{".yaml", ".yml"}, Unmarshal: yaml.Unmarshal},
+		{Name: FormatJSON, Extensions: []string{".json"}, Unmarshal: unmarshalJSON},
+		{Name: FormatJSONC, Extensions: []string{".jsonc"}, Unmarshal: unmarshalJSONC},
+		{Name: FormatTOML, Extensions: []string{".toml"}, Unmarshal: toml.Unmarshal},
+	}
+)
+
+// Format is a config file format.
+type Format struct {
+	// Name is the name of the format (i.e. "toml").
+	Name string
+	// Extensions are the file extensions used by the format (i.e. ".toml").
+	Extensions []string
+	// Unmarshal decodes data into v, a pointer to an empty interface.
+	// It should produce generic values: maps, slices, and scalars.
+	Unmarshal func(data []byte, v any) error
+}
+
+// RegisterFormat registers a config file format,
+// replacing any existing format with the same name.
+// The format is used for files with any of its extensions.
+func RegisterFormat(format Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	for i, f := range formats {
+		if f.Name == format.Name {
+			formats[i] = &format
+			return
+		}
+	}
+	formats = append(formats, &format)
+}
+
+// LookupFormat returns the format registered for name.
+func LookupFormat(name string) (*Format, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	return lookupFormatLocked(name)
+}
+
+// FormatForPath returns the format for path, based on its extension.
+// Defaults to YAML if the extension is not registered.
+func FormatForPath(path string) *Format {
+	ext := strings.ToLower(filepath.Ext(path))
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	// Search in reverse so that later registrations win.
+	for i := len(formats) - 1; i >= 0; i-- {
+		for _, e := range formats[i].Extensions {
+			if e == ext {
+				return formats[i]
+			}
+		}
+	}
+	f, _ := lookupFormatLocked(FormatYAML)
+	return f
+}
+
+func lookupFormatLocked(name string) (*Format, bool) {
+	for _, f := range formats {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// toYAML converts data in the given format to YAML.
+// YAML data is returned as is (so that line numbers are preserved).
+func toYAML(format *Format, data []byte) ([]byte, error) {
+	if format.Name == FormatYAML {
+		return data, nil
+	}
+	var raw any
+	if err := format.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", format.Name, err)
+	}
+	if raw == nil {
+		return []byte{}, nil
+	}
+	return yaml.Marshal(raw)
+}
+
+// unmarshalJSON is like [json.Unmarshal], but decodes integers
+// as int64 (rather than float64) so that large values are preserved.
+func unmarshalJSON(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if p, ok := v.(*any); ok {
+		*p = convertJSONNumbers(*p)
+	}
+	return nil
+}
+
+// convertJSONNumbers replaces each json.Number in v with an int64 or float64.
+func convertJSONNumbers(v any) any {
+	switch typed := v.(type) {
+	case json.Number:
+		if i, err := typed.Int64(); err == nil {
+			return i
+		}
+		f, _ := typed.Float64()
+		return f
+	case map[string]any:
+		for k, item := range typed {
+			typed[k] = convertJSONNumbers(item)
+		}
+	case []any:
+		for i, item := range typed {
+			typed[i] = convertJSONNumbers(item)
+		}
+	}
+	return v
+}
+
+// unmarshalJSONC decodes JSON that may contain comments and trailing commas.
+func unmarshalJSONC(data []byte, v any) error {
+	return unmarshalJSON(stripJSONC(data), v)
+}
+
+// stripJSONC removes comments and trailing commas from JSON data.
+// Removed comments are replaced with whitespace to preserve offsets.
+func stripJSONC(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				out = append(out, ' ')
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			out = append(out, ' ', ' ')
+			i += 2
+			for i < len(data) && !(data[i] == '*' && i+1 < len(data) && data[i+1] == '/') {
+				if data[i] == '\n' {
+					out = append(out, '\n')
+				} else {
+					out = append(out, ' ')
+				}
+				i++
+			}
+			if i < len(data) {
+				out = append(out, ' ', ' ')
+				i++
+			}
+		default:
+			out = append(out, c)
+		}
+	}
+	return stripTrailingCommas(out)
+}
+
+// stripTrailingCommas replaces commas that precede a closing bracket with a space.
+func stripTrailingCommas(data []byte) []byte {
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		if c == '"' {
+			inString = true
+			continue
+		}
+		if c != ',' {
+			continue
+		}
+		j := i + 1
+		for j < len(data) && strings.ContainsRune(" \t\r\n", rune(data[j])) {
+			j++
+		}
+		if j < len(data) && (data[j] == '}' || data[j] == ']') {
+			data[i] = ' '
+		}
+	}
+	return data
+}
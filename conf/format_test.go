@@ -0,0 +1,117 @@
+package conf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoader_Load_WithFormats(t *testing.T) {
+	for _, name := range []string{"config.yaml", "config.json", "config.jsonc", "config.toml"} {
+		t.Run(name, func(t *testing.T) {
+			config, err := NewLoader(&MyConfig{}, FixturePath(name)).Load()
+			assert.NoError(t, err)
+			assert.Equal(t, Fixture(), config)
+		})
+	}
+}
+
+func TestLoader_Load_WithFormat(t *testing.T) {
+	// Format option overrides the extension.
+	_, err := NewLoader(&MyConfig{}, FixturePath("config.yaml"), WithFormat(FormatTOML)).Load()
+	assert.ErrorContains(t, err, "config.yaml: toml:")
+
+	_, err = NewLoader(&MyConfig{}, FixturePath("config.yaml"), WithFormat("nope")).Load()
+	assert.ErrorContains(t, err, "unknown config format: nope")
+}
+
+func TestLoader_Load_WhenFormatError(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{FormatJSON, "json: invalid character"},
+		{FormatJSONC, "jsonc: invalid character"},
+		{FormatTOML, "toml:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			format, ok := LookupFormat(tt.format)
+			assert.True(t, ok)
+			_, err := toYAML(format, []byte("{nope"))
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestRegisterFormat(t *testing.T) {
+	defer func(orig []*Format) {
+		formats = orig
+	}(append([]*Format{}, formats...))
+
+	// A simple `key=value` format.
+	RegisterFormat(Format{
+		Name:       "kv",
+		Extensions: []string{".kv"},
+		Unmarshal: func(data []byte, v any) error {
+			values := map[string]any{}
+			for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+				key, value, ok := strings.Cut(line, "=")
+				if !ok {
+					return errors.New("missing =")
+				}
+				values[key] = value
+			}
+			*(v.(*any)) = values
+			return nil
+		},
+	})
+
+	format := FormatForPath("/path/to/config.KV")
+	assert.Equal(t, "kv", format.Name)
+
+	data, err := toYAML(format, []byte("name=aaa\ncount=10\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "count: \"10\"\nname: aaa\n", string(data))
+
+	// Re-registering replaces the existing format.
+	RegisterFormat(Format{Name: "kv", Extensions: []string{".keyval"}, Unmarshal: format.Unmarshal})
+	assert.Equal(t, FormatYAML, FormatForPath("config.kv").Name)
+	assert.Equal(t, "kv", FormatForPath("config.keyval").Name)
+}
+
+func TestFormatForPath(t *testing.T) {
+	assert.Equal(t, FormatYAML, FormatForPath("config.yml").Name)
+	assert.Equal(t, FormatJSON, FormatForPath("config.json").Name)
+	assert.Equal(t, FormatJSONC, FormatForPath("config.jsonc").Name)
+	assert.Equal(t, FormatTOML, FormatForPath("config.toml").Name)
+	// Defaults to YAML
+	assert.Equal(t, FormatYAML, FormatForPath("config").Name)
+	assert.Equal(t, FormatYAML, FormatForPath("config.conf").Name)
+}
+
+func TestStripJSONC(t *testing.T) {
+	input := "{\n  \"a\": \"http://x\", // c\n  /* multi\n  line */ \"b\": [1, 2,],\n  \"c\": \"\\\"/*\",\n}"
+	expected := "{\n  \"a\": \"http://x\",     \n          \n          \"b\": [1, 2 ],\n  \"c\": \"\\\"/*\" \n}"
+	assert.Equal(t, expected, string(stripJSONC([]byte(input))))
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	var v any
+	err := unmarshalJSON([]byte(`{"big": 9007199254740993, "f": 1.5, "list": [1]}`), &v)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"big":  int64(9007199254740993),
+		"f":    1.5,
+		"list": []any{int64(1)},
+	}, v)
+}
+
+func TestToYAML_WhenEmpty(t *testing.T) {
+	format, _ := LookupFormat(FormatTOML)
+	data, err := toYAML(format, []byte(""))
+	assert.NoError(t, err)
+	assert.Equal(t, "{}\n", string(data))
+}
@@ -1,83 +1,482 @@
 package conf
 
 import (
+	"crypto/sha256"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
+	"sync"
 
 	"github.com/caarlos0/env/v8"  // spell: disable-line
 	"github.com/creasty/defaults" // spell: disable-line
 	yaml "gopkg.in/yaml.v3"
 
+	"github.com/twelvelabs/termite/fsutil"
+	"github.com/twelvelabs/termite/run"
 	"github.com/twelvelabs/termite/validate"
 )
 
 var (
 	// for stubbing
 	defaultsSet   = defaults.Set
+	osGetwd       = os.Getwd
 	osReadFile    = os.ReadFile
 	yamlUnmarshal = yaml.Unmarshal
 )
 
+// Config layer names, in order of precedence (lowest to highest).
+const (
+	LayerDefault = "default"
+	LayerSystem  = "system"
+	LayerUser    = "user"
+	LayerProject = "project"
+	LayerEnv     = "env"
+	LayerFlags   = "flags"
+)
+
+// Layer is a config file that is merged into the config struct.
+type Layer struct {
+	// Name is the layer name (i.e. [LayerUser]).
+	Name string
+	// Path is the path to the config file.
+	Path string
+}
+
+// LoaderOpt allows setting optional loader params.
+type LoaderOpt func(opts *loaderOptions)
+
+type loaderOptions struct {
+	systemPath    string
+	projectFiles  []string
+	format        string
+	secretClient  *run.Client
+	migrations    []Migration
+	onDeprecation func(d Deprecation)
+	profiles      bool
+	profileEnv    string
+	strict        bool
+	strictEnv     string
+	onUnknownKey  func(k UnknownKey)
+	envPrefix     string
+	autoEnv       bool
+}
+
+// WithSystemFile sets the path to a system-wide config file.
+// It has the lowest precedence of all config files.
+func WithSystemFile(path string) LoaderOpt {
+	return func(opts *loaderOptions) {
+		opts.systemPath = path
+	}
+}
+
+// WithProjectFile sets the name of a project config file that is searched
+// for in the working dir and each of its parents (the nearest one wins).
+// When multiple names are given, the first one found in a dir is used.
+// It has the highest precedence of all config files.
+func WithProjectFile(names ...string) LoaderOpt {
+	return func(opts *loaderOptions) {
+		opts.projectFiles = names
+	}
+}
+
+// WithFormat sets the format (i.e. [FormatTOML]) used to decode all config files.
+// By default, the format is determined by each file's extension (see [FormatForPath]).
+func WithFormat(name string) LoaderOpt {
+	return func(opts *loaderOptions) {
+		opts.format = name
+	}
+}
+
+// WithSecretClient sets the client used to run "cmd:" [Secret] references.
+// Defaults to [run.NewClient].
+func WithSecretClient(client *run.Client) LoaderOpt {
+	return func(opts *loaderOptions) {
+		opts.secretClient = client
+	}
+}
+
+// WithAppLayers configures git-style config layering for app.
+// Values are merged from (lowest to highest precedence):
+//
+//   - defaults (from struct tags)
+//   - the system config file ([SystemConfigFile])
+//   - the user config file (the loader path)
+//   - the project config file (".<app>.yaml" in the working dir or a parent,
+//     or any other registered extension, i.e. ".<app>.toml")
+//   - environment variables
+//   - command-line flags (see [Loader.BindFlags])
+func WithAppLayers(app string) LoaderOpt {
+	return func(opts *loaderOptions) {
+		opts.systemPath = SystemConfigFile(app)
+		opts.projectFiles = []string{}
+		formatsMu.RLock()
+		defer formatsMu.RUnlock()
+		for _, f := range formats {
+			for _, ext := range f.Extensions {
+				opts.projectFiles = append(opts.projectFiles, "."+app+ext)
+			}
+		}
+	}
+}
+
 // NewLoader returns a new [Loader].
-func NewLoader[C any](config C, path string) *Loader[C] {
+func NewLoader[C any](config C, path string, opts ...LoaderOpt) *Loader[C] {
 	v := reflect.ValueOf(config)
 	if v.Type().Kind() != reflect.Ptr {
 		panic("config struct must be a pointer")
 	}
-	return &Loader[C]{
+	l := &Loader[C]{
 		Config: config,
 		Path:   path,
 	}
+	for _, opt := range opts {
+		opt(&l.opts)
+	}
+	return l
 }
 
-// Loader populates and validates config data from a file.
+// Loader populates and validates config data from one or more files.
+//
+// Loader methods are safe for concurrent use. The first load fills in
+// the struct passed to [NewLoader]. After that, config structs are never
+// modified: each reload (or [Loader.Set], etc.) creates a new struct and
+// makes it the current one. When the config may be reloaded concurrently
+// (see [Loader.Watch]), use [Loader.Current] rather than the Config field
+// (or the struct passed to [NewLoader]) to access the config.
 type Loader[C any] struct {
+	// Config is the current config.
 	Config C
-	Path   string
+	// Path is the path to the user config file.
+	Path string
+
+	opts            loaderOptions
+	mu              sync.RWMutex
+	loaded          bool
+	filled          bool
+	origins         map[string]Origin
+	flags           []*flagValue
+	selectedProfile string
+	subscribers     map[int]func(old C, new C)
+	nextSubID       int
 
-	loaded bool
+	// reportMu guards reported, since configs are decoded
+	// concurrently (see [Loader.Watch]).
+	reportMu sync.Mutex
+	// reported holds a hash of each config file whose
+	// deprecations have been reported.
+	reported map[string][sha256.Size]byte
 }
 
-// Load reads the config file at Path into the Config struct and returns it.
+// Load reads the config files into the Config struct and returns it.
 // Subsequent calls will bypass the read and just return the cached struct.
 func (l *Loader[C]) Load() (C, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	if l.loaded {
 		return l.Config, nil
 	}
 	return l.load()
 }
 
-// Reload forces a reread of the config file at Path.
+// Reload forces a reread of the config files into a new config struct.
 func (l *Loader[C]) Reload() (C, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	return l.load()
 }
 
+// Current returns the current config.
+func (l *Loader[C]) Current() C {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.Config
+}
+
+// Layers returns the config files to be merged, in order of precedence
+// (lowest to highest). Files that do not exist are skipped when loading.
+func (l *Loader[C]) Layers() []Layer {
+	layers := []Layer{}
+	if l.opts.systemPath != "" {
+		layers = append(layers, Layer{Name: LayerSystem, Path: l.opts.systemPath})
+	}
+	if l.Path != "" {
+		layers = append(layers, Layer{Name: LayerUser, Path: l.Path})
+	}
+	if len(l.opts.projectFiles) > 0 {
+		if path := findProjectFile(l.opts.projectFiles); path != "" {
+			layers = append(layers, Layer{Name: LayerProject, Path: path})
+		}
+	}
+	return layers
+}
+
 func (l *Loader[C]) load() (C, error) {
 	l.loaded = true
-	// Set defaults
-	if err := defaultsSet(l.Config); err != nil {
+	config, origins, err := l.decode(nil)
+	if err != nil {
 		return l.Config, err
 	}
-	// Try to read the file
-	bytes, err := osReadFile(l.Path)
-	if err != nil && !errors.Is(err, os.ErrNotExist) {
-		// Probably a permissions error
-		return l.Config, err
-	} else if err == nil {
-		// Unmarshal
-		err = yamlUnmarshal(bytes, l.Config)
+	// Validate (invalid configs are never made current)
+	if err := validate.Struct(config); err != nil {
+		return config, err
+	}
+	l.set(config, origins)
+	return l.Config, nil
+}
+
+// decode reads each layer into a new config struct.
+// If overrides contains a layer path, that data is used instead of the file.
+// Deprecations and unknown keys are only reported when there are
+// no overrides (so that they are reported once per load).
+func (l *Loader[C]) decode(overrides map[string][]byte) (C, map[string]Origin, error) {
+	config := l.newConfig()
+	origins := map[string]Origin{}
+	for _, f := range configFields(reflect.ValueOf(config)) {
+		origins[f.Key] = Origin{Layer: LayerDefault}
+	}
+	// Set defaults
+	if err := defaultsSet(config); err != nil {
+		return config, nil, err
+	}
+	// Determine the profile (if enabled)
+	profile, err := l.activeProfile(overrides)
+	if err != nil {
+		return config, nil, err
+	}
+	profileFound := profile == DefaultProfile
+	// Merge in each layer
+	unknown := []UnknownKey{}
+	for _, layer := range l.Layers() {
+		source, ok, err := l.readSource(layer, overrides)
+		if err != nil {
+			return config, nil, err
+		} else if !ok {
+			continue
+		}
+		bytes, err := l.convertYAML(layer.Path, source)
+		if err != nil {
+			return config, nil, err
+		}
+		if overrides == nil && l.strictEnabled() {
+			keys, err := l.unknownFileKeys(layer.Path, source, bytes)
+			if err != nil {
+				return config, nil, fmt.Errorf("%s: %w", layer.Path, err)
+			}
+			unknown = append(unknown, keys...)
+		}
+		bytes, err = l.migrateData(layer.Path, bytes, overrides == nil)
 		if err != nil {
-			return l.Config, err
+			return config, nil, fmt.Errorf("%s: %w", layer.Path, err)
+		}
+		bytes, found, err := l.applyProfile(bytes, profile)
+		if err != nil {
+			return config, nil, fmt.Errorf("%s: %w", layer.Path, err)
+		}
+		profileFound = profileFound || found
+		err = yamlUnmarshal(bytes, config)
+		if err != nil {
+			return config, nil, fmt.Errorf("%s: %w", layer.Path, err)
+		}
+		for _, key := range fileKeys(bytes, reflect.TypeOf(config)) {
+			origins[key] = Origin{Layer: layer.Name, Path: layer.Path}
+		}
+	}
+	if !profileFound {
+		return config, nil, fmt.Errorf("unknown profile: %s", profile)
+	}
+	// Check for unknown keys and env vars
+	if overrides == nil && l.strictEnabled() {
+		unknown = append(unknown, l.unknownEnvVars()...)
+		if err := l.reportUnknownKeys(unknown); err != nil {
+			return config, nil, err
 		}
 	}
 	// Override values passed in via ENV var
-	if err := env.Parse(l.Config); err != nil {
-		return l.Config, err
+	if err := env.ParseWithOptions(config, env.Options{Prefix: l.opts.envPrefix}); err != nil {
+		return config, nil, err
 	}
-	// Validate
-	if err := validate.Struct(l.Config); err != nil {
-		return l.Config, err
+	if err := l.applyAutoEnv(config); err != nil {
+		return config, nil, err
 	}
-	return l.Config, nil
+	for _, f := range l.envFields(reflect.ValueOf(config)) {
+		if _, ok := os.LookupEnv(f.EnvVar); ok && f.EnvVar != "" {
+			origins[f.Key] = Origin{Layer: LayerEnv, Path: f.EnvVar}
+		}
+	}
+	// Override values passed in via command-line flag
+	if err := applyFlags(config, l.flags); err != nil {
+		return config, nil, err
+	}
+	for _, fv := range l.flags {
+		if fv.changed() {
+			origins[fv.key] = Origin{Layer: LayerFlags, Path: fv.name}
+		}
+	}
+	bindSecrets(config, l.opts.secretClient)
+	return config, origins, nil
+}
+
+// readLayer reads the config file for layer, converts it to YAML,
+// and applies any pending migrations. Returns false if the file does not exist.
+// If overrides contains the layer path, that data is used instead of the file.
+func (l *Loader[C]) readLayer(layer Layer, overrides map[string][]byte, report bool) ([]byte, bool, error) {
+	bytes, ok, err := l.readYAML(layer, overrides)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	bytes, err = l.migrateData(layer.Path, bytes, report)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", layer.Path, err)
+	}
+	return bytes, true, nil
+}
+
+// readYAML reads the config file for layer and converts it to YAML.
+// Returns false if the file does not exist.
+// If overrides contains the layer path, that data is used instead of the file.
+func (l *Loader[C]) readYAML(layer Layer, overrides map[string][]byte) ([]byte, bool, error) {
+	source, ok, err := l.readSource(layer, overrides)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	bytes, err := l.convertYAML(layer.Path, source)
+	if err != nil {
+		return nil, false, err
+	}
+	return bytes, true, nil
+}
+
+// readSource reads the config file for layer (in its own format).
+// Returns false if the file does not exist.
+// If overrides contains the layer path, that data is used instead of the file.
+func (l *Loader[C]) readSource(layer Layer, overrides map[string][]byte) ([]byte, bool, error) {
+	if bytes, ok := overrides[layer.Path]; ok {
+		return bytes, true, nil
+	}
+	bytes, err := osReadFile(layer.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	} else if err != nil {
+		// Probably a permissions error
+		return nil, false, err
+	}
+	return bytes, true, nil
+}
+
+// convertYAML converts the source data of the config file at path to YAML (if needed).
+func (l *Loader[C]) convertYAML(path string, source []byte) ([]byte, error) {
+	format, err := l.format(path)
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := toYAML(format, source)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return bytes, nil
+}
+
+// newConfig returns a pointer to a new, zero value config struct.
+func (l *Loader[C]) newConfig() C {
+	return reflect.New(reflect.TypeOf(l.Config).Elem()).Interface().(C)
+}
+
+// set makes config the current config. The first time, config is copied
+// into the struct passed to [NewLoader] (so that references to it see the
+// loaded values). After that, the previous config struct is never modified,
+// since it may still be in use (see [Loader.Current]).
+func (l *Loader[C]) set(config C, origins map[string]Origin) {
+	if l.filled {
+		l.Config = config
+	} else {
+		reflect.ValueOf(l.Config).Elem().Set(reflect.ValueOf(config).Elem())
+		l.filled = true
+	}
+	l.origins = origins
+}
+
+// format returns the format used to decode the config file at path.
+func (l *Loader[C]) format(path string) (*Format, error) {
+	if l.opts.format == "" {
+		return FormatForPath(path), nil
+	}
+	format, ok := LookupFormat(l.opts.format)
+	if !ok {
+		return nil, fmt.Errorf("unknown config format: %s", l.opts.format)
+	}
+	return format, nil
+}
+
+// findProjectFile returns the path to the nearest file with one of names
+// in the working dir or any of its parents.
+func findProjectFile(names []string) string {
+	dir, err := osGetwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			if fsutil.PathExists(path) {
+				return path
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Origin describes the source of a config value.
+type Origin struct {
+	// Layer is the name of the layer that set the value (i.e. [LayerUser]).
+	Layer string
+	// Path is the config file path, the env var name for [LayerEnv],
+	// or the flag name for [LayerFlags].
+	Path string
+}
+
+// String returns the origin in `layer:path` format (i.e. "env:APP_DEBUG").
+func (o Origin) String() string {
+	if o.Path == "" {
+		return o.Layer
+	}
+	return fmt.Sprintf("%s:%s", o.Layer, o.Path)
+}
+
+// Value is a config value and its origin.
+type Value struct {
+	// Key is the dot separated path to the value (i.e. "database.url").
+	Key    string
+	Value  any
+	Origin Origin
+}
+
+// Origin returns the origin of the value at key (i.e. "database.url").
+// Only available after the config has been loaded.
+func (l *Loader[C]) Origin(key string) (Origin, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	origin, ok := l.origins[key]
+	return origin, ok
+}
+
+// Values returns each of the (leaf) config values, along with their
+// origins, in struct field order. Only available after the config has been loaded.
+func (l *Loader[C]) Values() []Value {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	values := []Value{}
+	for _, f := range configFields(reflect.ValueOf(l.Config)) {
+		values = append(values, Value{
+			Key:    f.Key,
+			Value:  f.Value.Interface(),
+			Origin: l.origins[f.Key],
+		})
+	}
+	return values
 }
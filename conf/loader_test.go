@@ -3,6 +3,7 @@ package conf
 import (
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -45,6 +46,15 @@ func FixturePath(names ...string) string {
 	return filepath.Join("testdata", name)
 }
 
+// writeFixture writes a config file named name to a temp dir
+// and returns its path.
+func writeFixture(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
 func FixtureWithDefaults() *MyConfig {
 	return &MyConfig{
 		Name:    "untitled",
@@ -139,8 +149,44 @@ func TestLoader_Load_WhenEnvError(t *testing.T) {
 }
 
 func TestLoader_LoadWhenValidationError(t *testing.T) {
-	_, err := NewLoader(&MyConfig{}, FixturePath("invalid.yaml")).Load()
+	config := &MyConfig{}
+	_, err := NewLoader(config, FixturePath("invalid.yaml")).Load()
 	assert.ErrorContains(t, err, "Count must be less than 100")
+	// Invalid configs should not be loaded.
+	assert.Equal(t, &MyConfig{}, config)
+}
+
+func TestLoader_Load_FillsConfig(t *testing.T) {
+	path := writeFixture(t, "config.yaml", "name: aaa\n")
+	config := &MyConfig{}
+	loader := NewLoader(config, path)
+
+	loaded, err := loader.Load()
+	assert.NoError(t, err)
+	assert.Same(t, config, loaded)
+	assert.Equal(t, "aaa", config.Name)
+
+	// Reloads create a new struct, leaving the loaded one unmodified.
+	assert.NoError(t, os.WriteFile(path, []byte("name: bbb\n"), 0600))
+	reloaded, err := loader.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, "bbb", reloaded.Name)
+	assert.Same(t, reloaded, loader.Current())
+	assert.Equal(t, "aaa", config.Name)
+}
+
+func TestLoader_ReloadWhenValidationError(t *testing.T) {
+	path := writeFixture(t, "config.yaml", "count: 2\n")
+	loader := NewLoader(&MyConfig{}, path)
+	config, err := loader.Load()
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.WriteFile(path, []byte("count: 200\n"), 0600))
+	_, err = loader.Reload()
+	assert.ErrorContains(t, err, "Count must be less than 100")
+	// The current config should be kept.
+	assert.Same(t, config, loader.Current())
+	assert.Equal(t, 2, config.Count)
 }
 
 func TestLoader_Reload(t *testing.T) {
@@ -156,3 +202,98 @@ func TestLoader_Reload(t *testing.T) {
 	_, err = loader.Reload()
 	assert.ErrorContains(t, err, "boom")
 }
+
+type LayeredConfig struct {
+	Name     string `default:"untitled"`
+	Database struct {
+		URL  string `yaml:"url" env:"LAYERED_DATABASE_URL"`
+		Pool int    `yaml:"pool" default:"5"`
+	} `yaml:"database"`
+	Labels map[string]string
+	Tags   []string
+}
+
+func TestLoader_Load_WithLayers(t *testing.T) {
+	wd, _ := filepath.Abs(FixturePath("layers/project/sub"))
+	stubs := gostub.StubFunc(&osGetwd, wd, nil)
+	defer stubs.Reset()
+	t.Setenv("LAYERED_DATABASE_URL", "postgres://env:5432/db")
+
+	loader := NewLoader(
+		&LayeredConfig{},
+		FixturePath("layers/user.yaml"),
+		WithSystemFile(FixturePath("layers/system.yaml")),
+		WithProjectFile(".myapp.yaml"),
+	)
+	project := filepath.Join(filepath.Dir(wd), ".myapp.yaml")
+	assert.Equal(t, []Layer{
+		{Name: LayerSystem, Path: FixturePath("layers/system.yaml")},
+		{Name: LayerUser, Path: FixturePath("layers/user.yaml")},
+		{Name: LayerProject, Path: project},
+	}, loader.Layers())
+
+	config, err := loader.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "system", config.Name)
+	assert.Equal(t, "postgres://env:5432/db", config.Database.URL)
+	assert.Equal(t, 10, config.Database.Pool)
+	assert.Equal(t, map[string]string{
+		"a": "system",
+		"b": "user",
+		"c": "project",
+	}, config.Labels)
+	assert.Equal(t, []string{"project"}, config.Tags)
+
+	origin, ok := loader.Origin("database.url")
+	assert.True(t, ok)
+	assert.Equal(t, "env:LAYERED_DATABASE_URL", origin.String())
+	origin, _ = loader.Origin("database.pool")
+	assert.Equal(t, Origin{Layer: LayerSystem, Path: FixturePath("layers/system.yaml")}, origin)
+	_, ok = loader.Origin("database")
+	assert.False(t, ok)
+
+	assert.Equal(t, []Value{
+		{
+			Key:    "name",
+			Value:  "system",
+			Origin: Origin{Layer: LayerSystem, Path: FixturePath("layers/system.yaml")},
+		},
+		{
+			Key:    "database.url",
+			Value:  "postgres://env:5432/db",
+			Origin: Origin{Layer: LayerEnv, Path: "LAYERED_DATABASE_URL"},
+		},
+		{
+			Key:    "database.pool",
+			Value:  10,
+			Origin: Origin{Layer: LayerSystem, Path: FixturePath("layers/system.yaml")},
+		},
+		{
+			Key:    "labels",
+			Value:  config.Labels,
+			Origin: Origin{Layer: LayerProject, Path: project},
+		},
+		{
+			Key:    "tags",
+			Value:  config.Tags,
+			Origin: Origin{Layer: LayerProject, Path: project},
+		},
+	}, loader.Values())
+}
+
+func TestLoader_Load_WithAppLayers(t *testing.T) {
+	stubs := gostub.StubFunc(&osGetwd, t.TempDir(), nil)
+	stubs.StubFunc(&isWindowsFunc, false)
+	defer stubs.Reset()
+
+	loader := NewLoader(&LayeredConfig{}, "", WithAppLayers("my-app"))
+	assert.Equal(t, []Layer{
+		{Name: LayerSystem, Path: filepath.Join("/etc", "my-app", "config.yaml")},
+	}, loader.Layers())
+
+	config, err := loader.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "untitled", config.Name)
+	origin, _ := loader.Origin("name")
+	assert.Equal(t, "default", origin.String())
+}
@@ -0,0 +1,389 @@
+package conf
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// VersionKey is the config file key that holds the file version
+// (the number of migrations that have been applied to it).
+const VersionKey = "version"
+
+// Migration upgrades a config file to the next version.
+type Migration struct {
+	// Description is a short summary of the change (i.e. "rename url to base_url").
+	Description string
+	// Deprecated maps the keys replaced by the migration to their replacements
+	// (or to an empty string if they were removed). Used to warn about old keys.
+	Deprecated map[string]string
+	// Apply migrates the root mapping node of the config file.
+	Apply func(root *yaml.Node) error
+}
+
+// RenameKey returns a migration that moves the value at key from to key to
+// (i.e. "database.url" to "db.url"). Comments are preserved.
+// If both keys are set, the value at key to is kept.
+func RenameKey(from string, to string) Migration {
+	return Migration{
+		Description: fmt.Sprintf("rename %s to %s", from, to),
+		Deprecated:  map[string]string{from: to},
+		Apply: func(root *yaml.Node) error {
+			fromPath, toPath := strings.Split(from, "."), strings.Split(to, ".")
+			key := findKeyNode(root, fromPath)
+			if key == nil {
+				return nil
+			}
+			value := findNode(root, fromPath)
+			if findNode(root, toPath) != nil {
+				unsetNode(root, fromPath)
+				return nil
+			}
+			if slices.Equal(fromPath[:len(fromPath)-1], toPath[:len(toPath)-1]) {
+				// Same parent, so rename in place (keeping the key order).
+				key.Value = toPath[len(toPath)-1]
+				return nil
+			}
+			unsetNode(root, fromPath)
+			setNode(root, toPath, value)
+			if k := findKeyNode(root, toPath); k != nil && k.HeadComment == "" {
+				k.HeadComment = key.HeadComment
+			}
+			return nil
+		},
+	}
+}
+
+// RemoveKey returns a migration that removes key.
+func RemoveKey(key string) Migration {
+	return Migration{
+		Description: fmt.Sprintf("remove %s", key),
+		Deprecated:  map[string]string{key: ""},
+		Apply: func(root *yaml.Node) error {
+			unsetNode(root, strings.Split(key, "."))
+			return nil
+		},
+	}
+}
+
+// MapMigration returns a migration that operates on the config file
+// as a raw map, for changes that are awkward to make to YAML nodes.
+// Comments in the file are not preserved.
+func MapMigration(description string, fn func(data map[string]any) error) Migration {
+	return Migration{
+		Description: description,
+		Apply: func(root *yaml.Node) error {
+			data := map[string]any{}
+			if err := root.Decode(&data); err != nil {
+				return err
+			}
+			if err := fn(data); err != nil {
+				return err
+			}
+			node := &yaml.Node{}
+			if err := node.Encode(data); err != nil {
+				return err
+			}
+			*root = *node
+			return nil
+		},
+	}
+}
+
+// Deprecation describes a deprecated key found in a config file.
+type Deprecation struct {
+	// Key is the deprecated key (i.e. "url").
+	Key string
+	// Replacement is the key that replaces it (if any).
+	Replacement string
+	// Path is the config file path.
+	Path string
+	// Line is the line number of the key in the config file.
+	Line int
+}
+
+// String returns a warning message for the deprecation.
+func (d Deprecation) String() string {
+	msg := fmt.Sprintf("%s:%d: %s is deprecated", d.Path, d.Line, d.Key)
+	if d.Replacement == "" {
+		return msg + " and will be ignored"
+	}
+	return fmt.Sprintf("%s, use %s instead", msg, d.Replacement)
+}
+
+// WithMigrations sets the migrations used to upgrade config files.
+// The current config version is the number of migrations, and files with
+// an older [VersionKey] (or none) have the remaining migrations applied,
+// in order, when loaded. Use [Loader.Migrate] to rewrite the user config file.
+func WithMigrations(migrations ...Migration) LoaderOpt {
+	return func(opts *loaderOptions) {
+		opts.migrations = migrations
+	}
+}
+
+// WithDeprecationHandler sets a func to be called for each deprecated key
+// found in an outdated config file when it is loaded. Deprecations are
+// reported once per loader, and again only if the file content changes
+// (so reloads, i.e. via [Loader.Watch], don't repeat the same warnings).
+func WithDeprecationHandler(fn func(d Deprecation)) LoaderOpt {
+	return func(opts *loaderOptions) {
+		opts.onDeprecation = fn
+	}
+}
+
+// MigrateOpt allows setting optional migrate params.
+type MigrateOpt func(opts *migrateOptions)
+
+type migrateOptions struct {
+	dryRun bool
+}
+
+// WithDryRun reports the changes that would be made
+// without writing the config file.
+func WithDryRun() MigrateOpt {
+	return func(opts *migrateOptions) {
+		opts.dryRun = true
+	}
+}
+
+// MigrationResult describes the changes made by [Loader.Migrate].
+type MigrationResult struct {
+	// Path is the config file path.
+	Path string
+	// FromVersion is the file version before migrating.
+	FromVersion int
+	// ToVersion is the file version after migrating.
+	ToVersion int
+	// Applied are the descriptions of each migration that was applied.
+	Applied []string
+	// BackupPath is where the original file was copied (empty for dry runs).
+	BackupPath string
+	// Diff is a unified diff of the changes.
+	Diff string
+}
+
+// Changed returns true if any migrations were applied.
+func (r *MigrationResult) Changed() bool {
+	return r.FromVersion != r.ToVersion
+}
+
+// Migrate applies any pending migrations to the config file at Path,
+// backing up the original file before rewriting it.
+// Only YAML config files are supported.
+func (l *Loader[C]) Migrate(opts ...MigrateOpt) (*MigrationResult, error) {
+	options := migrateOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result := &MigrationResult{Path: l.Path}
+	original, err := osReadFile(l.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return result, nil
+	} else if err != nil {
+		return nil, err
+	}
+	result.FromVersion, err = dataVersion(original)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", l.Path, err)
+	}
+	result.ToVersion = result.FromVersion
+	if result.FromVersion >= len(l.opts.migrations) {
+		return result, nil
+	}
+	for _, m := range l.opts.migrations[result.FromVersion:] {
+		result.Applied = append(result.Applied, m.Description)
+	}
+	// Pending migrations are applied when reading.
+	doc, err := l.readDocument()
+	if err != nil {
+		return nil, err
+	}
+	result.ToVersion = len(l.opts.migrations)
+
+	data, err := encodeDocument(doc, original)
+	if err != nil {
+		return nil, err
+	}
+	result.Diff, err = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(strings.TrimSuffix(string(original), "\n")),
+		B:        difflib.SplitLines(strings.TrimSuffix(string(data), "\n")),
+		FromFile: fmt.Sprintf("%s (version %d)", l.Path, result.FromVersion),
+		ToFile:   fmt.Sprintf("%s (version %d)", l.Path, result.ToVersion),
+		Context:  3,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if options.dryRun {
+		return result, nil
+	}
+	result.BackupPath, err = l.backup()
+	if err != nil {
+		return nil, err
+	}
+	return result, l.writeData(data)
+}
+
+// migrateData applies any pending migrations to the YAML data
+// of the config file at path. Data is returned as is if already current.
+// When report is true, deprecated keys are passed to the deprecation handler.
+func (l *Loader[C]) migrateData(path string, data []byte, report bool) ([]byte, error) {
+	if len(l.opts.migrations) == 0 {
+		return data, nil
+	}
+	// Syntax errors are left for the config unmarshal to report.
+	doc := &yaml.Node{}
+	if yaml.Unmarshal(data, doc) != nil || len(doc.Content) == 0 {
+		return data, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return data, nil
+	}
+	version, err := nodeVersion(root)
+	if err != nil {
+		return nil, err
+	}
+	if version == len(l.opts.migrations) {
+		return data, nil
+	}
+	report = report && l.opts.onDeprecation != nil && l.firstReport(path, data)
+	if err := l.migrateNode(path, root, report); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(doc)
+}
+
+// migrateNode applies any pending migrations to the root mapping node
+// of the config file at path and sets its version.
+func (l *Loader[C]) migrateNode(path string, root *yaml.Node, report bool) error {
+	if len(l.opts.migrations) == 0 {
+		return nil
+	}
+	version, err := nodeVersion(root)
+	if err != nil {
+		return err
+	}
+	current := len(l.opts.migrations)
+	if version > current {
+		return fmt.Errorf("config version %d is newer than supported version %d", version, current)
+	}
+	if version == current {
+		return nil
+	}
+	for i, m := range l.opts.migrations[version:] {
+		if report && l.opts.onDeprecation != nil {
+			l.reportDeprecations(path, root, m.Deprecated)
+		}
+		if m.Apply == nil {
+			continue
+		}
+		if err := m.Apply(root); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", version+i+1, m.Description, err)
+		}
+	}
+	versionNode := &yaml.Node{}
+	if err := versionNode.Encode(current); err != nil {
+		return err
+	}
+	setNode(root, []string{VersionKey}, versionNode)
+	return nil
+}
+
+// firstReport returns true if the deprecations in data, the content
+// of the config file at path, have not already been reported.
+func (l *Loader[C]) firstReport(path string, data []byte) bool {
+	sum := sha256.Sum256(data)
+	l.reportMu.Lock()
+	defer l.reportMu.Unlock()
+	if l.reported == nil {
+		l.reported = map[string][sha256.Size]byte{}
+	}
+	if prev, ok := l.reported[path]; ok && prev == sum {
+		return false
+	}
+	l.reported[path] = sum
+	return true
+}
+
+// reportDeprecations calls the deprecation handler for each
+// deprecated key in the root mapping node (sorted by line).
+func (l *Loader[C]) reportDeprecations(path string, root *yaml.Node, deprecated map[string]string) {
+	found := []Deprecation{}
+	for key, replacement := range deprecated {
+		if node := findNode(root, strings.Split(key, ".")); node != nil {
+			found = append(found, Deprecation{
+				Key:         key,
+				Replacement: replacement,
+				Path:        path,
+				Line:        node.Line,
+			})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Line < found[j].Line
+	})
+	for _, d := range found {
+		l.opts.onDeprecation(d)
+	}
+}
+
+// backup copies the config file at Path to "<path>.v<version>.bak"
+// (if it exists and is outdated) and returns the backup path.
+func (l *Loader[C]) backup() (string, error) {
+	data, err := osReadFile(l.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	version, err := dataVersion(data)
+	if err != nil || version >= len(l.opts.migrations) {
+		return "", err
+	}
+	info, err := os.Stat(l.Path)
+	if err != nil {
+		return "", err
+	}
+	path := fmt.Sprintf("%s.v%d.bak", l.Path, version)
+	if err := writeFileAtomic(path, data, info.Mode().Perm()); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// dataVersion returns the version in the YAML data (0 if not set).
+func dataVersion(data []byte) (int, error) {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return 0, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return 0, nil
+	}
+	return nodeVersion(doc.Content[0])
+}
+
+// nodeVersion returns the version in the root mapping node (0 if not set).
+func nodeVersion(root *yaml.Node) (int, error) {
+	node := findNode(root, []string{VersionKey})
+	if node == nil {
+		return 0, nil
+	}
+	version := 0
+	if err := node.Decode(&version); err != nil || version < 0 {
+		return 0, fmt.Errorf("line %d: invalid config version: %s", node.Line, node.Value)
+	}
+	return version, nil
+}
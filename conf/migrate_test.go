@@ -0,0 +1,201 @@
+package conf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v3"
+)
+
+type MigrateConfig struct {
+	BaseURL  string `yaml:"base_url"`
+	Database struct {
+		URL string `yaml:"url"`
+	} `yaml:"database"`
+	Tags []string
+}
+
+const migrateFixture = `# The API URL
+url: https://example.com # inline
+db_url: postgres://localhost/db
+legacy: true
+`
+
+var testMigrations = []Migration{
+	RenameKey("url", "base_url"),
+	RenameKey("db_url", "database.url"),
+	RemoveKey("legacy"),
+	MapMigration("default tags", func(data map[string]any) error {
+		if _, ok := data["tags"]; !ok {
+			data["tags"] = []string{"default"}
+		}
+		return nil
+	}),
+}
+
+func TestLoader_Migrations_Load(t *testing.T) {
+	path := writeFixture(t, "config.yaml", migrateFixture)
+	deprecations := []string{}
+	loader := NewLoader(&MigrateConfig{}, path,
+		WithMigrations(testMigrations...),
+		WithDeprecationHandler(func(d Deprecation) {
+			deprecations = append(deprecations, d.String())
+		}),
+	)
+
+	config, err := loader.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com", config.BaseURL)
+	assert.Equal(t, "postgres://localhost/db", config.Database.URL)
+	assert.Equal(t, []string{"default"}, config.Tags)
+	assert.Equal(t, []string{
+		path + ":2: url is deprecated, use base_url instead",
+		path + ":3: db_url is deprecated, use database.url instead",
+		path + ":4: legacy is deprecated and will be ignored",
+	}, deprecations)
+
+	// The file is not modified when loading.
+	assert.Equal(t, migrateFixture, readFile(t, path))
+
+	// Deprecations are only reported again if the file changes.
+	deprecations = []string{}
+	_, err = loader.Reload()
+	assert.NoError(t, err)
+	assert.NoError(t, loader.reloadAndSwap())
+	assert.Empty(t, deprecations)
+
+	assert.NoError(t, os.WriteFile(path, []byte("url: https://example.com\n"), 0600))
+	_, err = loader.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		path + ":1: url is deprecated, use base_url instead",
+	}, deprecations)
+}
+
+func TestLoader_Migrations_LoadWhenCurrent(t *testing.T) {
+	path := writeFixture(t, "config.yaml", "version: 4\nurl: https://example.com\n")
+	called := false
+	loader := NewLoader(&MigrateConfig{}, path,
+		WithMigrations(testMigrations...),
+		WithDeprecationHandler(func(d Deprecation) { called = true }),
+	)
+
+	config, err := loader.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "", config.BaseURL)
+	assert.Nil(t, config.Tags)
+	assert.False(t, called)
+}
+
+func TestLoader_Migrations_LoadErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		content string
+		err     string
+	}{
+		{"newer version", "version: 5\n", "config version 5 is newer than supported version 4"},
+		{"invalid version", "version: abc\n", "line 1: invalid config version: abc"},
+		{"negative version", "version: -1\n", "line 1: invalid config version: -1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			path := writeFixture(t, "config.yaml", tt.content)
+			loader := NewLoader(&MigrateConfig{}, path, WithMigrations(testMigrations...))
+			_, err := loader.Load()
+			assert.ErrorContains(t, err, path+": "+tt.err)
+		})
+	}
+
+	path := writeFixture(t, "config.yaml", "url: https://example.com\n")
+	loader := NewLoader(&MigrateConfig{}, path, WithMigrations(Migration{
+		Description: "broken",
+		Apply: func(root *yaml.Node) error {
+			return errors.New("boom")
+		},
+	}))
+	_, err := loader.Load()
+	assert.ErrorContains(t, err, "migration 1 (broken): boom")
+}
+
+func TestLoader_Migrate(t *testing.T) {
+	path := writeFixture(t, "config.yaml", migrateFixture)
+	assert.NoError(t, os.Chmod(path, 0640))
+	loader := NewLoader(&MigrateConfig{}, path, WithMigrations(testMigrations[:3]...))
+
+	// Dry run
+	result, err := loader.Migrate(WithDryRun())
+	assert.NoError(t, err)
+	assert.True(t, result.Changed())
+	assert.Equal(t, 0, result.FromVersion)
+	assert.Equal(t, 3, result.ToVersion)
+	assert.Equal(t, []string{
+		"rename url to base_url",
+		"rename db_url to database.url",
+		"remove legacy",
+	}, result.Applied)
+	assert.Equal(t, "", result.BackupPath)
+	assert.Equal(t, `--- `+path+` (version 0)
++++ `+path+` (version 3)
+@@ -1,4 +1,5 @@
+ # The API URL
+-url: https://example.com # inline
+-db_url: postgres://localhost/db
+-legacy: true
++base_url: https://example.com # inline
++database:
++  url: postgres://localhost/db
++version: 3
+`, result.Diff)
+	assert.Equal(t, migrateFixture, readFile(t, path))
+	assert.NoFileExists(t, path+".v0.bak")
+
+	// Migrate
+	result, err = loader.Migrate()
+	assert.NoError(t, err)
+	assert.Equal(t, path+".v0.bak", result.BackupPath)
+	assert.Equal(t, migrateFixture, readFile(t, result.BackupPath))
+	info, err := os.Stat(result.BackupPath)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	assert.Equal(t, "# The API URL\n"+
+		"base_url: https://example.com # inline\n"+
+		"database:\n"+
+		"  url: postgres://localhost/db\n"+
+		"version: 3\n", readFile(t, path))
+	assert.Equal(t, "https://example.com", loader.Current().BaseURL)
+
+	// Already current
+	result, err = loader.Migrate()
+	assert.NoError(t, err)
+	assert.False(t, result.Changed())
+	assert.Equal(t, "", result.Diff)
+}
+
+func TestLoader_Migrate_WhenMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	loader := NewLoader(&MigrateConfig{}, path, WithMigrations(testMigrations...))
+	result, err := loader.Migrate()
+	assert.NoError(t, err)
+	assert.False(t, result.Changed())
+	assert.NoFileExists(t, path)
+}
+
+func TestLoader_Set_WhenOutdated(t *testing.T) {
+	path := writeFixture(t, "config.yaml", "url: https://example.com\n")
+	loader := NewLoader(&MigrateConfig{}, path, WithMigrations(testMigrations[:1]...))
+
+	// Outdated files are migrated (and backed up) before being written.
+	assert.NoError(t, loader.Set("tags", "[a]"))
+	assert.Equal(t, "url: https://example.com\n", readFile(t, path+".v0.bak"))
+	assert.Equal(t, "base_url: https://example.com\nversion: 1\ntags:\n  - a\n", readFile(t, path))
+}
+
+func TestDeprecation_String(t *testing.T) {
+	d := Deprecation{Key: "url", Replacement: "base_url", Path: "config.yaml", Line: 3}
+	assert.Equal(t, "config.yaml:3: url is deprecated, use base_url instead", d.String())
+	d.Replacement = ""
+	assert.Equal(t, "config.yaml:3: url is deprecated and will be ignored", d.String())
+}
@@ -1,21 +1,37 @@
 package conf
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"syscall"
 )
 
 const (
 	appData       = "AppData"
 	localAppData  = "LocalAppData"
+	programData   = "ProgramData"
 	userHome      = "HOME"
+	xdgCacheHome  = "XDG_CACHE_HOME"
+	xdgConfigDirs = "XDG_CONFIG_DIRS"
 	xdgConfigHome = "XDG_CONFIG_HOME"
+	xdgDataDirs   = "XDG_DATA_DIRS"
 	xdgDataHome   = "XDG_DATA_HOME"
+	xdgRuntimeDir = "XDG_RUNTIME_DIR"
 	xdgStateHome  = "XDG_STATE_HOME"
 )
 
-var isWindowsFunc = isWindows
+var (
+	isWindowsFunc = isWindows
+	isDarwinFunc  = isDarwin
+	osGetuid      = os.Getuid
+	osRename      = os.Rename
+)
 
 // ConfigFile returns the default config path for the app.
 func ConfigFile(app string) string {
@@ -46,6 +62,23 @@ func ConfigDir(app string) string {
 	return path
 }
 
+// SystemConfigFile returns the default system-wide config path for the app.
+func SystemConfigFile(app string) string {
+	return filepath.Join(SystemConfigDir(app), "config.yaml")
+}
+
+// SystemConfigDir returns the path to the system-wide config dir for the app.
+// Path precedence:
+//
+//   - $ProgramData/$name (windows only)
+//   - /etc/$name
+func SystemConfigDir(app string) string {
+	if a := os.Getenv(programData); isWindowsFunc() && a != "" {
+		return filepath.Join(a, app)
+	}
+	return filepath.Join(string(filepath.Separator), "etc", app)
+}
+
 // DataDir returns the path to the local state dir for the app.
 // Path precedence:
 //
@@ -84,6 +117,248 @@ func StateDir(app string) string {
 	return path
 }
 
+// CacheDir returns the path to the local cache dir for the app.
+// Path precedence:
+//
+//   - $XDG_CACHE_HOME/$name
+//   - $LocalAppData/$name/cache (windows only)
+//   - $HOME/Library/Caches/$name (macOS only)
+//   - $HOME/.cache/$name
+func CacheDir(app string) string {
+	var path string
+	if a := os.Getenv(xdgCacheHome); a != "" {
+		path = filepath.Join(a, app)
+	} else if b := os.Getenv(localAppData); isWindowsFunc() && b != "" {
+		path = filepath.Join(b, app, "cache")
+	} else if c, _ := os.UserHomeDir(); isDarwinFunc() {
+		path = filepath.Join(c, "Library", "Caches", app)
+	} else {
+		path = filepath.Join(c, ".cache", app)
+	}
+	return path
+}
+
+// RuntimeDir returns the path to the runtime dir for the app
+// (for sockets, pid files, etc).
+// Path precedence:
+//
+//   - $XDG_RUNTIME_DIR/$name (if absolute)
+//   - $TMPDIR/$name-$uid
+//
+// The fallback is in a shared temp dir, so it includes the user ID
+// to avoid collisions between users. Use [EnsureRuntimeDir] to create it
+// safely.
+func RuntimeDir(app string) string {
+	if a := os.Getenv(xdgRuntimeDir); filepath.IsAbs(a) {
+		return filepath.Join(a, app)
+	}
+	name := app
+	if uid := osGetuid(); uid >= 0 {
+		name = fmt.Sprintf("%s-%d", app, uid)
+	}
+	return filepath.Join(os.TempDir(), name)
+}
+
+// EnsureRuntimeDir creates [RuntimeDir] (if it does not exist) with 0700
+// permissions and returns its path. Because the fallback is in a shared
+// temp dir, an error is returned if the path is not a dir (i.e. a symlink),
+// or (except on Windows) if it is not owned by the current user or is
+// accessible by anyone else.
+func EnsureRuntimeDir(app string) (string, error) {
+	dir := RuntimeDir(app)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("runtime dir is not a dir: %s", dir)
+	}
+	uid := osGetuid()
+	if uid < 0 {
+		return dir, nil
+	}
+	if owner, ok := fileOwner(info); ok && owner != uid {
+		return "", fmt.Errorf("runtime dir is not owned by the current user: %s", dir)
+	}
+	if perm := info.Mode().Perm(); perm != 0700 {
+		return "", fmt.Errorf("runtime dir has insecure permissions (%#o): %s", perm, dir)
+	}
+	return dir, nil
+}
+
+// ConfigDirs returns the config dirs to search for the app,
+// in order of preference:
+//
+//   - [ConfigDir]
+//   - $dir/$name for each absolute dir in $XDG_CONFIG_DIRS
+//
+// If $XDG_CONFIG_DIRS is not set, it defaults to "/Library/Application Support"
+// on macOS and "/etc/xdg" everywhere else.
+func ConfigDirs(app string) []string {
+	fallback := []string{filepath.Join(string(filepath.Separator), "etc", "xdg")}
+	if isDarwinFunc() {
+		fallback = []string{filepath.Join(string(filepath.Separator), "Library", "Application Support")}
+	}
+	return append([]string{ConfigDir(app)}, searchDirs(xdgConfigDirs, fallback, app)...)
+}
+
+// DataDirs returns the data dirs to search for the app,
+// in order of preference:
+//
+//   - [DataDir]
+//   - $dir/$name for each absolute dir in $XDG_DATA_DIRS
+//
+// If $XDG_DATA_DIRS is not set, it defaults to "/Library/Application Support"
+// on macOS and "/usr/local/share:/usr/share" everywhere else.
+func DataDirs(app string) []string {
+	fallback := []string{
+		filepath.Join(string(filepath.Separator), "usr", "local", "share"),
+		filepath.Join(string(filepath.Separator), "usr", "share"),
+	}
+	if isDarwinFunc() {
+		fallback = []string{filepath.Join(string(filepath.Separator), "Library", "Application Support")}
+	}
+	return append([]string{DataDir(app)}, searchDirs(xdgDataDirs, fallback, app)...)
+}
+
+// FindConfigFile returns the path to the first existing file named filename
+// in [ConfigDirs], or an [fs.ErrNotExist] error if there is none.
+func FindConfigFile(app string, filename string) (string, error) {
+	return findFile(ConfigDirs(app), filename)
+}
+
+// FindDataFile returns the path to the first existing file named filename
+// in [DataDirs], or an [fs.ErrNotExist] error if there is none.
+func FindDataFile(app string, filename string) (string, error) {
+	return findFile(DataDirs(app), filename)
+}
+
+// LegacyDir returns the path to the pre-XDG dot dir for the app
+// (i.e. $HOME/.myapp).
+func LegacyDir(app string) string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, "."+app)
+}
+
+// MigrateLegacyDir moves the legacy dir to dir (i.e. [LegacyDir] to [ConfigDir]).
+// Nothing is done if legacy does not exist, or if dir already exists
+// (so it is safe to call on every run). Returns true if the dir was moved.
+// If the dirs are on different filesystems, legacy is copied then removed
+// (any other rename error is returned).
+func MigrateLegacyDir(legacy string, dir string) (bool, error) {
+	info, err := os.Stat(legacy)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if !info.IsDir() {
+		return false, fmt.Errorf("legacy path is not a dir: %s", legacy)
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return false, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0700); err != nil {
+		return false, err
+	}
+	if err := osRename(legacy, dir); err == nil {
+		return true, nil
+	} else if !errors.Is(err, syscall.EXDEV) {
+		return false, err
+	}
+	if err := copyDir(legacy, dir); err != nil {
+		_ = os.RemoveAll(dir)
+		return false, fmt.Errorf("unable to migrate %s: %w", legacy, err)
+	}
+	return true, os.RemoveAll(legacy)
+}
+
+// searchDirs returns $dir/$name for each absolute dir in the
+// list of dirs in env var (or fallback if it is not set).
+func searchDirs(env string, fallback []string, app string) []string {
+	dirs := fallback
+	if value := os.Getenv(env); value != "" {
+		dirs = strings.Split(value, string(os.PathListSeparator))
+	}
+	paths := []string{}
+	for _, dir := range dirs {
+		// Relative paths are invalid per the XDG spec.
+		if filepath.IsAbs(dir) {
+			paths = append(paths, filepath.Join(dir, app))
+		}
+	}
+	return paths
+}
+
+// findFile returns the path to the first existing file named filename in dirs.
+func findFile(dirs []string, filename string) (string, error) {
+	for _, dir := range dirs {
+		path := filepath.Join(dir, filename)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%s: %w", filename, fs.ErrNotExist)
+}
+
+// copyDir recursively copies the dir src to dst, preserving permissions.
+func copyDir(src string, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+// copyFile copies the file src to dst.
+func copyFile(src string, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
 func isWindows() bool {
 	return runtime.GOOS == "windows"
 }
+
+func isDarwin() bool {
+	return runtime.GOOS == "darwin"
+}
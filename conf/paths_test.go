@@ -1,8 +1,12 @@
 package conf
 
 import (
+	"io/fs"
+	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"syscall"
 	"testing"
 
 	"github.com/prashantv/gostub" // spell: disable-line
@@ -74,6 +78,45 @@ func TestConfigDir(t *testing.T) {
 	}
 }
 
+func TestSystemConfigDir(t *testing.T) {
+	tests := []struct {
+		desc        string
+		ProgramData string
+		windows     bool
+		expected    string
+	}{
+		{
+			desc:        "[non-windows] default",
+			ProgramData: "PROGRAM_DATA_DIR",
+			windows:     false,
+			expected:    filepath.Join(string(filepath.Separator), "etc", "my-app"),
+		},
+		{
+			desc:        "[windows] default",
+			ProgramData: "",
+			windows:     true,
+			expected:    filepath.Join(string(filepath.Separator), "etc", "my-app"),
+		},
+		{
+			desc:        "[windows] program data",
+			ProgramData: "PROGRAM_DATA_DIR",
+			windows:     true,
+			expected:    filepath.Join("PROGRAM_DATA_DIR", "my-app"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			stubs := gostub.StubFunc(&isWindowsFunc, tt.windows)
+			defer stubs.Reset()
+
+			t.Setenv("ProgramData", tt.ProgramData)
+
+			assert.Equal(t, tt.expected, SystemConfigDir("my-app"))
+			assert.Equal(t, filepath.Join(tt.expected, "config.yaml"), SystemConfigFile("my-app"))
+		})
+	}
+}
+
 func TestStateDir_WhenWindows(t *testing.T) {
 	tests := []struct {
 		desc           string
@@ -204,7 +247,404 @@ func TestDataDir_WhenWindows(t *testing.T) {
 	}
 }
 
+func TestCacheDir(t *testing.T) {
+	tests := []struct {
+		desc           string
+		HOME           string
+		LocalAppData   string
+		XDG_CACHE_HOME string
+		windows        bool
+		darwin         bool
+		expected       string
+	}{
+		{
+			desc:     "[linux] default",
+			HOME:     "HOME_DIR",
+			expected: filepath.Join("HOME_DIR", ".cache", "my-app"),
+		},
+		{
+			desc:           "[linux] xdg",
+			HOME:           "HOME_DIR",
+			XDG_CACHE_HOME: "XDG_DIR",
+			expected:       filepath.Join("XDG_DIR", "my-app"),
+		},
+		{
+			desc:     "[darwin] default",
+			HOME:     "HOME_DIR",
+			darwin:   true,
+			expected: filepath.Join("HOME_DIR", "Library", "Caches", "my-app"),
+		},
+		{
+			desc:           "[darwin] xdg",
+			HOME:           "HOME_DIR",
+			XDG_CACHE_HOME: "XDG_DIR",
+			darwin:         true,
+			expected:       filepath.Join("XDG_DIR", "my-app"),
+		},
+		{
+			desc:     "[windows] default",
+			HOME:     "HOME_DIR",
+			windows:  true,
+			expected: filepath.Join("HOME_DIR", ".cache", "my-app"),
+		},
+		{
+			desc:         "[windows] app data",
+			HOME:         "HOME_DIR",
+			LocalAppData: "APP_DATA_DIR",
+			windows:      true,
+			expected:     filepath.Join("APP_DATA_DIR", "my-app", "cache"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			stubs := gostub.StubFunc(&isWindowsFunc, tt.windows)
+			stubs.StubFunc(&isDarwinFunc, tt.darwin)
+			defer stubs.Reset()
+
+			t.Setenv("HOME", tt.HOME)
+			t.Setenv("LocalAppData", tt.LocalAppData)
+			t.Setenv("XDG_CACHE_HOME", tt.XDG_CACHE_HOME)
+
+			assert.Equal(t, tt.expected, CacheDir("my-app"))
+		})
+	}
+}
+
+func TestRuntimeDir(t *testing.T) {
+	tmp := t.TempDir()
+	tests := []struct {
+		desc            string
+		XDG_RUNTIME_DIR string
+		uid             int
+		expected        string
+	}{
+		{
+			desc:            "xdg",
+			XDG_RUNTIME_DIR: filepath.Join(tmp, "run"),
+			uid:             1000,
+			expected:        filepath.Join(tmp, "run", "my-app"),
+		},
+		{
+			desc:            "fallback when relative",
+			XDG_RUNTIME_DIR: "run",
+			uid:             1000,
+			expected:        filepath.Join(tmp, "my-app-1000"),
+		},
+		{
+			desc:     "fallback",
+			uid:      1000,
+			expected: filepath.Join(tmp, "my-app-1000"),
+		},
+		{
+			desc:     "fallback without uid",
+			uid:      -1,
+			expected: filepath.Join(tmp, "my-app"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			stubs := gostub.StubFunc(&osGetuid, tt.uid)
+			defer stubs.Reset()
+
+			t.Setenv("TMPDIR", tmp)
+			t.Setenv("TMP", tmp)
+			t.Setenv("XDG_RUNTIME_DIR", tt.XDG_RUNTIME_DIR)
+
+			assert.Equal(t, tt.expected, RuntimeDir("my-app"))
+		})
+	}
+}
+
+func TestEnsureRuntimeDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are not supported on windows")
+	}
+
+	t.Run("creates the dir", func(t *testing.T) {
+		tmp := t.TempDir()
+		t.Setenv("XDG_RUNTIME_DIR", tmp)
+
+		dir, err := EnsureRuntimeDir("my-app")
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(tmp, "my-app"), dir)
+		info, err := os.Stat(dir)
+		assert.NoError(t, err)
+		assert.Equal(t, fs.FileMode(0700), info.Mode().Perm())
+
+		// Subsequent calls succeed.
+		_, err = EnsureRuntimeDir("my-app")
+		assert.NoError(t, err)
+	})
+
+	t.Run("returns an error when a symlink", func(t *testing.T) {
+		tmp := t.TempDir()
+		t.Setenv("XDG_RUNTIME_DIR", tmp)
+		target := t.TempDir()
+		assert.NoError(t, os.Chmod(target, 0700))
+		assert.NoError(t, os.Symlink(target, filepath.Join(tmp, "my-app")))
+
+		_, err := EnsureRuntimeDir("my-app")
+		assert.EqualError(t, err, "runtime dir is not a dir: "+filepath.Join(tmp, "my-app"))
+	})
+
+	t.Run("returns an error when insecure", func(t *testing.T) {
+		tmp := t.TempDir()
+		t.Setenv("XDG_RUNTIME_DIR", tmp)
+		assert.NoError(t, os.Mkdir(filepath.Join(tmp, "my-app"), 0700))
+		assert.NoError(t, os.Chmod(filepath.Join(tmp, "my-app"), 0755))
+
+		_, err := EnsureRuntimeDir("my-app")
+		assert.EqualError(t, err, "runtime dir has insecure permissions (0755): "+filepath.Join(tmp, "my-app"))
+	})
+
+	t.Run("returns an error when owned by another user", func(t *testing.T) {
+		stubs := gostub.StubFunc(&osGetuid, os.Getuid()+1)
+		defer stubs.Reset()
+
+		tmp := t.TempDir()
+		t.Setenv("XDG_RUNTIME_DIR", tmp)
+
+		_, err := EnsureRuntimeDir("my-app")
+		assert.EqualError(t, err, "runtime dir is not owned by the current user: "+filepath.Join(tmp, "my-app"))
+	})
+}
+
+func TestConfigDirs(t *testing.T) {
+	root := string(filepath.Separator)
+	tests := []struct {
+		desc            string
+		XDG_CONFIG_DIRS string
+		darwin          bool
+		expected        []string
+	}{
+		{
+			desc: "[linux] default",
+			expected: []string{
+				filepath.Join("XDG_DIR", "my-app"),
+				filepath.Join(root, "etc", "xdg", "my-app"),
+			},
+		},
+		{
+			desc:   "[darwin] default",
+			darwin: true,
+			expected: []string{
+				filepath.Join("XDG_DIR", "my-app"),
+				filepath.Join(root, "Library", "Application Support", "my-app"),
+			},
+		},
+		{
+			desc: "xdg",
+			XDG_CONFIG_DIRS: strings.Join([]string{
+				filepath.Join(root, "a"), "relative", filepath.Join(root, "b"),
+			}, string(os.PathListSeparator)),
+			darwin: true,
+			expected: []string{
+				filepath.Join("XDG_DIR", "my-app"),
+				filepath.Join(root, "a", "my-app"),
+				filepath.Join(root, "b", "my-app"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			stubs := gostub.StubFunc(&isDarwinFunc, tt.darwin)
+			defer stubs.Reset()
+
+			t.Setenv("XDG_CONFIG_HOME", "XDG_DIR")
+			t.Setenv("XDG_CONFIG_DIRS", tt.XDG_CONFIG_DIRS)
+
+			assert.Equal(t, tt.expected, ConfigDirs("my-app"))
+		})
+	}
+}
+
+func TestDataDirs(t *testing.T) {
+	root := string(filepath.Separator)
+	tests := []struct {
+		desc          string
+		XDG_DATA_DIRS string
+		darwin        bool
+		expected      []string
+	}{
+		{
+			desc: "[linux] default",
+			expected: []string{
+				filepath.Join("XDG_DIR", "my-app"),
+				filepath.Join(root, "usr", "local", "share", "my-app"),
+				filepath.Join(root, "usr", "share", "my-app"),
+			},
+		},
+		{
+			desc:   "[darwin] default",
+			darwin: true,
+			expected: []string{
+				filepath.Join("XDG_DIR", "my-app"),
+				filepath.Join(root, "Library", "Application Support", "my-app"),
+			},
+		},
+		{
+			desc:          "xdg",
+			XDG_DATA_DIRS: filepath.Join(root, "a"),
+			expected: []string{
+				filepath.Join("XDG_DIR", "my-app"),
+				filepath.Join(root, "a", "my-app"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			stubs := gostub.StubFunc(&isDarwinFunc, tt.darwin)
+			defer stubs.Reset()
+
+			t.Setenv("XDG_DATA_HOME", "XDG_DIR")
+			t.Setenv("XDG_DATA_DIRS", tt.XDG_DATA_DIRS)
+
+			assert.Equal(t, tt.expected, DataDirs("my-app"))
+		})
+	}
+}
+
+func TestFindConfigFile(t *testing.T) {
+	home := t.TempDir()
+	system := t.TempDir()
+	other := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("XDG_CONFIG_DIRS", other+string(os.PathListSeparator)+system)
+
+	writeFile := func(dir string) string {
+		path := filepath.Join(dir, "my-app", "config.yaml")
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+		assert.NoError(t, os.WriteFile(path, []byte("key: value\n"), 0600))
+		return path
+	}
+
+	_, err := FindConfigFile("my-app", "config.yaml")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	systemPath := writeFile(system)
+	path, err := FindConfigFile("my-app", "config.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, systemPath, path)
+
+	// Dirs are not matched.
+	assert.NoError(t, os.MkdirAll(filepath.Join(other, "my-app", "config.yaml"), 0700))
+	path, err = FindConfigFile("my-app", "config.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, systemPath, path)
+
+	homePath := writeFile(home)
+	path, err = FindConfigFile("my-app", "config.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, homePath, path)
+}
+
+func TestFindDataFile(t *testing.T) {
+	system := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_DIRS", system)
+
+	expected := filepath.Join(system, "my-app", "data.db")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(expected), 0700))
+	assert.NoError(t, os.WriteFile(expected, []byte{}, 0600))
+
+	path, err := FindDataFile("my-app", "data.db")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, path)
+}
+
+func TestLegacyDir(t *testing.T) {
+	t.Setenv("HOME", "HOME_DIR")
+	t.Setenv("USERPROFILE", "HOME_DIR")
+	assert.Equal(t, filepath.Join("HOME_DIR", ".my-app"), LegacyDir("my-app"))
+}
+
+func TestMigrateLegacyDir(t *testing.T) {
+	newLegacyDir := func(t *testing.T) string {
+		t.Helper()
+		legacy := filepath.Join(t.TempDir(), ".my-app")
+		assert.NoError(t, os.MkdirAll(filepath.Join(legacy, "nested"), 0700))
+		assert.NoError(t, os.WriteFile(filepath.Join(legacy, "config.yaml"), []byte("a: 1\n"), 0600))
+		assert.NoError(t, os.WriteFile(filepath.Join(legacy, "nested", "data"), []byte("b"), 0644))
+		return legacy
+	}
+	assertMigrated := func(t *testing.T, legacy string, dir string) {
+		t.Helper()
+		assert.NoDirExists(t, legacy)
+		data, err := os.ReadFile(filepath.Join(dir, "config.yaml"))
+		assert.NoError(t, err)
+		assert.Equal(t, "a: 1\n", string(data))
+		info, err := os.Stat(filepath.Join(dir, "nested", "data"))
+		assert.NoError(t, err)
+		if runtime.GOOS != "windows" {
+			assert.Equal(t, fs.FileMode(0644), info.Mode().Perm())
+		}
+	}
+
+	t.Run("moves the legacy dir", func(t *testing.T) {
+		legacy := newLegacyDir(t)
+		dir := filepath.Join(t.TempDir(), "xdg", "my-app")
+
+		moved, err := MigrateLegacyDir(legacy, dir)
+		assert.NoError(t, err)
+		assert.True(t, moved)
+		assertMigrated(t, legacy, dir)
+
+		// Subsequent calls are a no-op.
+		moved, err = MigrateLegacyDir(legacy, dir)
+		assert.NoError(t, err)
+		assert.False(t, moved)
+	})
+
+	t.Run("copies when on a different filesystem", func(t *testing.T) {
+		stubs := gostub.StubFunc(&osRename, &os.LinkError{Op: "rename", Err: syscall.EXDEV})
+		defer stubs.Reset()
+
+		legacy := newLegacyDir(t)
+		dir := filepath.Join(t.TempDir(), "my-app")
+
+		moved, err := MigrateLegacyDir(legacy, dir)
+		assert.NoError(t, err)
+		assert.True(t, moved)
+		assertMigrated(t, legacy, dir)
+	})
+
+	t.Run("returns other rename errors", func(t *testing.T) {
+		stubs := gostub.StubFunc(&osRename, &os.LinkError{Op: "rename", Err: syscall.EPERM})
+		defer stubs.Reset()
+
+		legacy := newLegacyDir(t)
+		dir := filepath.Join(t.TempDir(), "my-app")
+
+		moved, err := MigrateLegacyDir(legacy, dir)
+		assert.ErrorIs(t, err, syscall.EPERM)
+		assert.False(t, moved)
+		assert.DirExists(t, legacy)
+		assert.NoDirExists(t, dir)
+	})
+
+	t.Run("does nothing when the dir exists", func(t *testing.T) {
+		legacy := newLegacyDir(t)
+		dir := t.TempDir()
+
+		moved, err := MigrateLegacyDir(legacy, dir)
+		assert.NoError(t, err)
+		assert.False(t, moved)
+		assert.DirExists(t, legacy)
+		assert.NoFileExists(t, filepath.Join(dir, "config.yaml"))
+	})
+
+	t.Run("returns an error when legacy is a file", func(t *testing.T) {
+		legacy := filepath.Join(t.TempDir(), ".my-app")
+		assert.NoError(t, os.WriteFile(legacy, []byte{}, 0600))
+
+		moved, err := MigrateLegacyDir(legacy, filepath.Join(t.TempDir(), "my-app"))
+		assert.EqualError(t, err, "legacy path is not a dir: "+legacy)
+		assert.False(t, moved)
+	})
+}
+
 func TestIsWindows(t *testing.T) {
 	// Got to get that coverage, yo.
 	assert.Equal(t, (runtime.GOOS == "windows"), isWindows())
+	assert.Equal(t, (runtime.GOOS == "darwin"), isDarwin())
 }
@@ -0,0 +1,12 @@
+//go:build windows
+
+package conf
+
+import (
+	"io/fs"
+)
+
+// fileOwner is not supported on Windows (file ownership is ACL based).
+func fileOwner(info fs.FileInfo) (int, bool) {
+	return 0, false
+}
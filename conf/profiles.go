@@ -0,0 +1,364 @@
+package conf
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultProfile is the name of the profile made up of the top-level
+	// config keys. All other profiles inherit from it.
+	DefaultProfile = "default"
+	// ProfilesKey is the config file key that holds the named profiles.
+	ProfilesKey = "profiles"
+	// CurrentProfileKey is the user config file key that holds
+	// the current profile (see [Loader.UseProfile]).
+	CurrentProfileKey = "current_profile"
+	// ProfileFlag is the name of the flag registered by [Loader.BindFlags]
+	// when profiles are enabled.
+	ProfileFlag = "profile"
+)
+
+var profileNameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// WithProfiles enables named profiles (i.e. for switching between
+// staging and production). Each profile is a section in the config file
+// that overrides the top-level (default profile) values:
+//
+//	current_profile: staging
+//	base_url: https://api.example.com
+//	profiles:
+//	  staging:
+//	    base_url: https://staging.example.com
+//
+// The profile is selected by (highest precedence first):
+//
+//   - the profile flag (see [Loader.BindFlags]) or [Loader.SelectProfile]
+//   - the env var named envVar (if not empty)
+//   - the current profile persisted in the user config file
+//
+// When editing the user config file (i.e. [Loader.Set]),
+// values are written to the selected profile.
+func WithProfiles(envVar string) LoaderOpt {
+	return func(opts *loaderOptions) {
+		opts.profiles = true
+		opts.profileEnv = envVar
+	}
+}
+
+// SelectProfile selects the profile for this process,
+// overriding the env var and current profile. It is not persisted.
+// The config must be loaded (or reloaded) after selecting.
+func (l *Loader[C]) SelectProfile(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.selectedProfile = name
+}
+
+// CurrentProfile returns the name of the selected profile.
+func (l *Loader[C]) CurrentProfile() (string, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.activeProfile(nil)
+}
+
+// Profiles returns the names of the profiles in each of the config files,
+// sorted, with [DefaultProfile] first.
+func (l *Loader[C]) Profiles() ([]string, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if !l.opts.profiles {
+		return nil, errors.New("profiles not enabled")
+	}
+	names := map[string]bool{}
+	for _, layer := range l.Layers() {
+		data, ok, err := l.readLayer(layer, nil, false)
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+		doc := &yaml.Node{}
+		if yaml.Unmarshal(data, doc) != nil || len(doc.Content) == 0 {
+			continue
+		}
+		if profiles := findNode(doc.Content[0], []string{ProfilesKey}); profiles != nil {
+			for _, name := range mappingKeys(profiles) {
+				names[name] = true
+			}
+		}
+	}
+	delete(names, DefaultProfile)
+	sorted := []string{}
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return append([]string{DefaultProfile}, sorted...), nil
+}
+
+// CreateProfile adds an empty profile to the user config file.
+func (l *Loader[C]) CreateProfile(name string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	doc, err := l.readProfileDocument(name)
+	if err != nil {
+		return err
+	}
+	root := doc.Content[0]
+	if name == DefaultProfile || findNode(root, []string{ProfilesKey, name}) != nil {
+		return fmt.Errorf("profile already exists: %s", name)
+	}
+	setNode(root, []string{ProfilesKey, name}, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+	return l.writeDocument(doc)
+}
+
+// CopyProfile copies the profile src to a new profile dst in the user config file.
+// Copying [DefaultProfile] copies the top-level values.
+func (l *Loader[C]) CopyProfile(src string, dst string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	doc, err := l.readProfileDocument(dst)
+	if err != nil {
+		return err
+	}
+	root := doc.Content[0]
+	if dst == DefaultProfile || findNode(root, []string{ProfilesKey, dst}) != nil {
+		return fmt.Errorf("profile already exists: %s", dst)
+	}
+
+	var section *yaml.Node
+	if src == DefaultProfile {
+		section = copyNode(root)
+		for _, key := range []string{ProfilesKey, CurrentProfileKey, VersionKey} {
+			unsetNode(section, []string{key})
+		}
+	} else if node := findNode(root, []string{ProfilesKey, src}); node != nil {
+		section = copyNode(node)
+	} else {
+		return fmt.Errorf("unknown profile: %s", src)
+	}
+	setNode(root, []string{ProfilesKey, dst}, section)
+	return l.writeDocument(doc)
+}
+
+// DeleteProfile removes a profile from the user config file.
+// If it is the current profile, the current profile is reset to [DefaultProfile].
+func (l *Loader[C]) DeleteProfile(name string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if name == DefaultProfile {
+		return errors.New("unable to delete the default profile")
+	}
+	doc, err := l.readProfileDocument(name)
+	if err != nil {
+		return err
+	}
+	root := doc.Content[0]
+	if !unsetNode(root, []string{ProfilesKey, name}) {
+		return fmt.Errorf("unknown profile: %s", name)
+	}
+	if current := findNode(root, []string{CurrentProfileKey}); current != nil && current.Value == name {
+		unsetNode(root, []string{CurrentProfileKey})
+	}
+	return l.writeDocument(doc)
+}
+
+// UseProfile persists name as the current profile in the user config file,
+// then reloads the config.
+func (l *Loader[C]) UseProfile(name string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	doc, err := l.readProfileDocument(name)
+	if err != nil {
+		return err
+	}
+	root := doc.Content[0]
+	if name == DefaultProfile {
+		unsetNode(root, []string{CurrentProfileKey})
+	} else {
+		setNode(root, []string{CurrentProfileKey}, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name})
+	}
+	// Unknown profiles are reported when the config is decoded.
+	return l.writeDocument(doc)
+}
+
+// readProfileDocument validates the profile name and returns
+// the user config file document.
+func (l *Loader[C]) readProfileDocument(name string) (*yaml.Node, error) {
+	if !l.opts.profiles {
+		return nil, errors.New("profiles not enabled")
+	}
+	if !profileNameRegexp.MatchString(name) {
+		return nil, fmt.Errorf("invalid profile name: %q", name)
+	}
+	return l.readDocument()
+}
+
+// activeProfile returns the name of the selected profile.
+// If overrides contains the user config file path, that data is used
+// to determine the current profile instead of the file.
+func (l *Loader[C]) activeProfile(overrides map[string][]byte) (string, error) {
+	if !l.opts.profiles {
+		return DefaultProfile, nil
+	}
+	if l.selectedProfile != "" {
+		return l.selectedProfile, nil
+	}
+	if name := os.Getenv(l.opts.profileEnv); l.opts.profileEnv != "" && name != "" {
+		return name, nil
+	}
+	if l.Path == "" {
+		return DefaultProfile, nil
+	}
+	data, ok, err := l.readLayer(Layer{Name: LayerUser, Path: l.Path}, overrides, false)
+	if err != nil || !ok {
+		return DefaultProfile, err
+	}
+	doc := &yaml.Node{}
+	if yaml.Unmarshal(data, doc) != nil || len(doc.Content) == 0 {
+		return DefaultProfile, nil
+	}
+	if current := findNode(doc.Content[0], []string{CurrentProfileKey}); current != nil && current.Value != "" {
+		return current.Value, nil
+	}
+	return DefaultProfile, nil
+}
+
+// profilePrefix returns the path to the selected profile section
+// in the user config file (empty for the default profile).
+func (l *Loader[C]) profilePrefix() ([]string, error) {
+	profile, err := l.activeProfile(nil)
+	if err != nil || profile == DefaultProfile {
+		return []string{}, err
+	}
+	return []string{ProfilesKey, profile}, nil
+}
+
+// profilePath returns the path to key in the user config file
+// for the selected profile.
+func (l *Loader[C]) profilePath(key string) ([]string, error) {
+	prefix, err := l.profilePrefix()
+	if err != nil {
+		return nil, err
+	}
+	return append(prefix, strings.Split(key, ".")...), nil
+}
+
+// applyProfile removes the profile keys from the YAML data and merges
+// the section for profile (if any) over the top-level values.
+// Returns true if the data contains the profile.
+func (l *Loader[C]) applyProfile(data []byte, profile string) ([]byte, bool, error) {
+	if !l.opts.profiles {
+		return data, false, nil
+	}
+	doc := &yaml.Node{}
+	if yaml.Unmarshal(data, doc) != nil || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return data, false, nil
+	}
+	root := doc.Content[0]
+	profiles := findNode(root, []string{ProfilesKey})
+	hasCurrent := unsetNode(root, []string{CurrentProfileKey})
+	if profiles == nil && !hasCurrent {
+		return data, false, nil
+	}
+	unsetNode(root, []string{ProfilesKey})
+
+	found := false
+	if profiles != nil {
+		if profiles.Kind != yaml.MappingNode {
+			return nil, false, fmt.Errorf("line %d: %s must be a mapping", profiles.Line, ProfilesKey)
+		}
+		if section := findNode(profiles, []string{profile}); section != nil {
+			if section.Kind != yaml.MappingNode && section.Tag != "!!null" {
+				return nil, false, fmt.Errorf("line %d: profile %s must be a mapping", section.Line, profile)
+			}
+			mergeNodes(root, section)
+			found = true
+		}
+	}
+	data, err := yaml.Marshal(doc)
+	return data, found, err
+}
+
+// mergeNodes merges the mapping node src into dst.
+// Nested mappings are merged; all other values in src replace those in dst.
+func mergeNodes(dst *yaml.Node, src *yaml.Node) {
+	if src.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		existing := findNode(dst, []string{key.Value})
+		if existing != nil && existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+			mergeNodes(existing, value)
+			continue
+		}
+		setNode(dst, []string{key.Value}, copyNode(value))
+	}
+}
+
+// copyNode returns a deep copy of node.
+func copyNode(node *yaml.Node) *yaml.Node {
+	copied := *node
+	copied.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		copied.Content[i] = copyNode(child)
+	}
+	return &copied
+}
+
+// mappingKeys returns the keys of the mapping node.
+func mappingKeys(node *yaml.Node) []string {
+	keys := []string{}
+	if node.Kind != yaml.MappingNode {
+		return keys
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keys = append(keys, node.Content[i].Value)
+	}
+	return keys
+}
+
+// profileFlag is the flag used to select a profile.
+type profileFlag struct {
+	value  string
+	choose func(name string)
+}
+
+func (f *profileFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.value
+}
+
+func (f *profileFlag) Set(s string) error {
+	f.value = s
+	f.choose(s)
+	return nil
+}
+
+func (f *profileFlag) Type() string {
+	return "string"
+}
+
+// bindProfileFlag registers the profile flag in fs.
+func (l *Loader[C]) bindProfileFlag(fs *flag.FlagSet) error {
+	if fs.Lookup(ProfileFlag) != nil {
+		return fmt.Errorf("flag redefined: %s", ProfileFlag)
+	}
+	usage := "Config profile to use"
+	if l.opts.profileEnv != "" {
+		usage += fmt.Sprintf(" (env: %s)", l.opts.profileEnv)
+	}
+	fs.Var(&profileFlag{choose: l.SelectProfile}, ProfileFlag, usage)
+	return nil
+}
@@ -0,0 +1,176 @@
+package conf
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ProfileConfig struct {
+	BaseURL  string `yaml:"base_url" default:"https://api.example.com"`
+	Database struct {
+		URL  string `yaml:"url"`
+		Pool int    `yaml:"pool" default:"5"`
+	} `yaml:"database"`
+}
+
+const profilesFixture = `current_profile: staging
+database:
+  url: postgres://prod/db
+profiles:
+  staging:
+    base_url: https://staging.example.com
+    database:
+      url: postgres://staging/db
+  dev:
+    base_url: http://localhost
+`
+
+func TestLoader_Profiles_Load(t *testing.T) {
+	path := writeFixture(t, "config.yaml", profilesFixture)
+	loader := NewLoader(&ProfileConfig{}, path, WithProfiles("APP_PROFILE"))
+
+	// Persisted current profile
+	config, err := loader.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "https://staging.example.com", config.BaseURL)
+	assert.Equal(t, "postgres://staging/db", config.Database.URL)
+	assert.Equal(t, 5, config.Database.Pool)
+	origin, _ := loader.Origin("base_url")
+	assert.Equal(t, Origin{Layer: LayerUser, Path: path}, origin)
+
+	// Env var
+	t.Setenv("APP_PROFILE", "dev")
+	config, err = loader.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost", config.BaseURL)
+	// Inherited from the default profile
+	assert.Equal(t, "postgres://prod/db", config.Database.URL)
+
+	// Selected
+	loader.SelectProfile(DefaultProfile)
+	config, err = loader.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, "https://api.example.com", config.BaseURL)
+	assert.Equal(t, "postgres://prod/db", config.Database.URL)
+	current, err := loader.CurrentProfile()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultProfile, current)
+
+	// Unknown
+	loader.SelectProfile("unknown")
+	_, err = loader.Reload()
+	assert.EqualError(t, err, "unknown profile: unknown")
+}
+
+func TestLoader_Profiles_Flag(t *testing.T) {
+	path := writeFixture(t, "config.yaml", profilesFixture)
+	loader := NewLoader(&ProfileConfig{}, path, WithProfiles("APP_PROFILE"))
+	t.Setenv("APP_PROFILE", "staging")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	assert.NoError(t, loader.BindFlags(fs))
+	assert.NoError(t, fs.Parse([]string{"-profile", "dev"}))
+
+	config, err := loader.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost", config.BaseURL)
+
+	buf := &bytes.Buffer{}
+	fs.SetOutput(buf)
+	fs.PrintDefaults()
+	assert.Contains(t, buf.String(), "Config profile to use (env: APP_PROFILE)")
+
+	assert.ErrorContains(t, loader.BindFlags(fs), "flag redefined: profile")
+}
+
+func TestLoader_Profiles_List(t *testing.T) {
+	path := writeFixture(t, "config.yaml", profilesFixture)
+	loader := NewLoader(&ProfileConfig{}, path, WithProfiles(""))
+	names, err := loader.Profiles()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"default", "dev", "staging"}, names)
+
+	_, err = NewLoader(&ProfileConfig{}, path).Profiles()
+	assert.EqualError(t, err, "profiles not enabled")
+}
+
+func TestLoader_Profiles_Manage(t *testing.T) {
+	path := writeFixture(t, "config.yaml", "base_url: https://prod.example.com\n")
+	loader := NewLoader(&ProfileConfig{}, path, WithProfiles(""))
+
+	assert.NoError(t, loader.CreateProfile("staging"))
+	assert.EqualError(t, loader.CreateProfile("staging"), "profile already exists: staging")
+	assert.EqualError(t, loader.CreateProfile("default"), "profile already exists: default")
+	assert.EqualError(t, loader.CreateProfile("a.b"), `invalid profile name: "a.b"`)
+
+	assert.NoError(t, loader.CopyProfile("default", "backup"))
+	assert.EqualError(t, loader.CopyProfile("missing", "other"), "unknown profile: missing")
+
+	assert.NoError(t, loader.UseProfile("staging"))
+	assert.Equal(t, "https://prod.example.com", loader.Current().BaseURL)
+	assert.EqualError(t, loader.UseProfile("missing"), "unknown profile: missing")
+
+	// Values are set in the current profile.
+	assert.NoError(t, loader.Set("base_url", "https://staging.example.com"))
+	assert.Equal(t, "https://staging.example.com", loader.Current().BaseURL)
+	assert.NoError(t, loader.CopyProfile("staging", "qa"))
+
+	assert.Equal(t, `base_url: https://prod.example.com
+profiles:
+  staging:
+    base_url: https://staging.example.com
+  backup:
+    base_url: https://prod.example.com
+  qa:
+    base_url: https://staging.example.com
+current_profile: staging
+`, readFile(t, path))
+
+	// Saved to the current profile.
+	loader.Current().Database.Pool = 10
+	assert.NoError(t, loader.Save())
+	assert.NoError(t, loader.Unset("base_url"))
+	assert.Equal(t, `base_url: https://prod.example.com
+profiles:
+  staging:
+    database:
+      pool: 10
+  backup:
+    base_url: https://prod.example.com
+  qa:
+    base_url: https://staging.example.com
+current_profile: staging
+`, readFile(t, path))
+
+	assert.EqualError(t, loader.DeleteProfile("default"), "unable to delete the default profile")
+	assert.EqualError(t, loader.DeleteProfile("missing"), "unknown profile: missing")
+	assert.NoError(t, loader.DeleteProfile("backup"))
+	assert.NoError(t, loader.DeleteProfile("staging"))
+	assert.NoError(t, loader.UseProfile("default"))
+	assert.Equal(t, `base_url: https://prod.example.com
+profiles:
+  qa:
+    base_url: https://staging.example.com
+`, readFile(t, path))
+	current, err := loader.CurrentProfile()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultProfile, current)
+}
+
+func TestLoader_Profiles_Errors(t *testing.T) {
+	path := writeFixture(t, "config.yaml", "profiles: [a, b]\n")
+	loader := NewLoader(&ProfileConfig{}, path, WithProfiles(""))
+	_, err := loader.Load()
+	assert.EqualError(t, err, path+": line 1: profiles must be a mapping")
+
+	path = writeFixture(t, "config.yaml", "current_profile: dev\nprofiles:\n  dev: [a]\n")
+	loader = NewLoader(&ProfileConfig{}, path, WithProfiles(""))
+	_, err = loader.Load()
+	assert.EqualError(t, err, path+": line 3: profile dev must be a mapping")
+
+	loader = NewLoader(&ProfileConfig{}, path)
+	assert.EqualError(t, loader.CreateProfile("dev"), "profiles not enabled")
+}
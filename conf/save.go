@@ -0,0 +1,369 @@
+package conf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/twelvelabs/termite/fsutil"
+	"github.com/twelvelabs/termite/validate"
+)
+
+var (
+	// for stubbing
+	ensureDirWritable = fsutil.EnsureDirWritable
+	writeFileAtomic   = fsutil.WriteFileAtomic
+)
+
+// Get returns the config value at key (i.e. "database.url").
+// Keys may also address entries in map values (i.e. "labels.team").
+func (l *Loader[C]) Get(key string) (any, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.loaded {
+		if _, err := l.load(); err != nil {
+			return nil, err
+		}
+	}
+	v, _, err := lookupKey(reflect.ValueOf(l.Config), key)
+	if err != nil {
+		return nil, err
+	}
+	if !v.IsValid() {
+		return nil, nil
+	}
+	return v.Interface(), nil
+}
+
+// Set sets the value at key in the config file at Path
+// (in the selected profile section if profiles are enabled),
+// then reloads the config (into a new config struct).
+// The value is parsed as YAML and must be valid for the field type
+// (i.e. "true" for a bool, or "[a, b]" for a slice).
+// Comments, key order, and other keys in the file are preserved.
+func (l *Loader[C]) Set(key string, value string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, typ, err := lookupKey(reflect.ValueOf(l.newConfig()), key)
+	if err != nil {
+		return err
+	}
+	typed := reflect.New(typ)
+	if err := yaml.Unmarshal([]byte(value), typed.Interface()); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	node := &yaml.Node{}
+	if err := node.Encode(persistValue(typed.Elem())); err != nil {
+		return err
+	}
+
+	doc, err := l.readDocument()
+	if err != nil {
+		return err
+	}
+	path, err := l.profilePath(key)
+	if err != nil {
+		return err
+	}
+	setNode(doc.Content[0], path, node)
+	return l.writeDocument(doc)
+}
+
+// Unset removes key from the config file at Path, then reloads the config
+// (into a new config struct).
+// Comments, key order, and other keys in the file are preserved.
+func (l *Loader[C]) Unset(key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, _, err := lookupKey(reflect.ValueOf(l.newConfig()), key); err != nil {
+		return err
+	}
+	doc, err := l.readDocument()
+	if err != nil {
+		return err
+	}
+	path, err := l.profilePath(key)
+	if err != nil {
+		return err
+	}
+	if !unsetNode(doc.Content[0], path) {
+		return nil
+	}
+	return l.writeDocument(doc)
+}
+
+// Save writes the current config struct (see [Loader.Current]) to the config
+// file at Path (to the selected profile section if profiles are enabled).
+// The saved values are then loaded into a new config struct.
+//
+// Values already in the file are updated. Other values are only written if
+// they differ from what the remaining layers (defaults, system, project, etc.)
+// would produce. Values set via env vars are never written.
+// Comments, key order, and other keys in the file are preserved.
+func (l *Loader[C]) Save() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	doc, err := l.readDocument()
+	if err != nil {
+		return err
+	}
+	root := doc.Content[0]
+	prefix, err := l.profilePrefix()
+	if err != nil {
+		return err
+	}
+
+	// Determine what the config would be without the values being saved
+	// (the whole file, or just the selected profile section).
+	baseData := []byte{}
+	if len(prefix) > 0 {
+		baseDoc := copyNode(doc)
+		setNode(baseDoc.Content[0], prefix, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+		if baseData, err = encodeDocument(baseDoc, nil); err != nil {
+			return err
+		}
+	}
+	base, _, err := l.decode(map[string][]byte{l.Path: baseData})
+	if err != nil {
+		return err
+	}
+	baseFields := map[string]reflect.Value{}
+	for _, f := range configFields(reflect.ValueOf(base)) {
+		baseFields[f.Key] = f.Value
+	}
+
+	for _, f := range configFields(reflect.ValueOf(l.Config)) {
+		if layer := l.origins[f.Key].Layer; layer == LayerEnv || layer == LayerFlags {
+			continue
+		}
+		path := append(slices.Clone(prefix), strings.Split(f.Key, ".")...)
+		value := persistValue(f.Value)
+		inFile := findNode(root, path) != nil
+		changed := !reflect.DeepEqual(value, persistValue(baseFields[f.Key]))
+		if !inFile && !changed {
+			continue
+		}
+		node := &yaml.Node{}
+		if err := node.Encode(value); err != nil {
+			return err
+		}
+		setNode(root, path, node)
+	}
+	return l.writeDocument(doc)
+}
+
+// readDocument returns the YAML document in the config file at Path,
+// with any pending migrations applied.
+// An empty document is returned if the file does not exist.
+func (l *Loader[C]) readDocument() (*yaml.Node, error) {
+	if l.Path == "" {
+		return nil, errors.New("config path not set")
+	}
+	if format, err := l.format(l.Path); err != nil {
+		return nil, err
+	} else if format.Name != FormatYAML {
+		return nil, fmt.Errorf("unable to write %s config file: only YAML is supported", format.Name)
+	}
+
+	doc := &yaml.Node{Kind: yaml.DocumentNode}
+	data, err := osReadFile(l.Path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	} else if err == nil {
+		if err := yaml.Unmarshal(data, doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", l.Path, err)
+		}
+	}
+	if len(doc.Content) == 0 {
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: expected a mapping", l.Path)
+	}
+	if err := l.migrateNode(l.Path, doc.Content[0], false); err != nil {
+		return nil, fmt.Errorf("%s: %w", l.Path, err)
+	}
+	return doc, nil
+}
+
+// writeDocument validates the config that would result from doc,
+// atomically writes it to Path, and makes it the current config.
+// If the file is outdated, the original is backed up first.
+func (l *Loader[C]) writeDocument(doc *yaml.Node) error {
+	original, _ := osReadFile(l.Path)
+	data, err := encodeDocument(doc, original)
+	if err != nil {
+		return err
+	}
+	if _, err := l.backup(); err != nil {
+		return err
+	}
+	return l.writeData(data)
+}
+
+// writeData validates the config that would result from data,
+// atomically writes it to Path, and makes it the current config.
+func (l *Loader[C]) writeData(data []byte) error {
+	config, origins, err := l.decode(map[string][]byte{l.Path: data})
+	if err != nil {
+		return err
+	}
+	if err := validate.Struct(config); err != nil {
+		return err
+	}
+
+	if err := ensureDirWritable(filepath.Dir(l.Path)); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(l.Path, data, fsutil.DefaultFileMode); err != nil {
+		return err
+	}
+	l.loaded = true
+	l.set(config, origins)
+	return nil
+}
+
+// encodeDocument encodes doc using 2 space indentation.
+// The leading document marker in original (if any) is kept.
+func encodeDocument(doc *yaml.Node, original []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if bytes.HasPrefix(original, []byte("---")) {
+		buf.WriteString("---\n")
+	}
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// lookupKey returns the value and type addressed by key in the config struct v.
+// The value is invalid if key addresses a missing map entry.
+func lookupKey(v reflect.Value, key string) (reflect.Value, reflect.Type, error) {
+	for _, f := range configFields(v) {
+		if f.Key == key {
+			return f.Value, f.Field.Type, nil
+		}
+		rest, ok := strings.CutPrefix(key, f.Key+".")
+		if !ok || f.Field.Type.Kind() != reflect.Map || f.Field.Type.Key().Kind() != reflect.String {
+			continue
+		}
+		if strings.Contains(rest, ".") {
+			break
+		}
+		entry := reflect.Value{}
+		if !f.Value.IsNil() {
+			entry = f.Value.MapIndex(reflect.ValueOf(rest).Convert(f.Field.Type.Key()))
+		}
+		return entry, f.Field.Type.Elem(), nil
+	}
+	return reflect.Value{}, nil, fmt.Errorf("unknown config key: %s", key)
+}
+
+// findNode returns the value node at path in the mapping node (or nil).
+func findNode(node *yaml.Node, path []string) *yaml.Node {
+	for _, name := range path {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == name {
+				next = node.Content[i+1]
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+// findKeyNode returns the key node at path in the mapping node (or nil).
+func findKeyNode(node *yaml.Node, path []string) *yaml.Node {
+	parent := findNode(node, path[:len(path)-1])
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return nil
+	}
+	var key *yaml.Node
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == path[len(path)-1] {
+			key = parent.Content[i]
+		}
+	}
+	return key
+}
+
+// setNode sets the value node at path in the mapping node,
+// creating any intermediate mappings. Comments on an existing value are kept.
+func setNode(node *yaml.Node, path []string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != path[0] {
+			continue
+		}
+		if len(path) > 1 {
+			child := node.Content[i+1]
+			if child.Kind != yaml.MappingNode {
+				child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				node.Content[i+1] = child
+			}
+			setNode(child, path[1:], value)
+			return
+		}
+		old := node.Content[i+1]
+		if value.LineComment == "" {
+			value.LineComment = old.LineComment
+		}
+		if value.HeadComment == "" {
+			value.HeadComment = old.HeadComment
+		}
+		if value.FootComment == "" {
+			value.FootComment = old.FootComment
+		}
+		node.Content[i+1] = value
+		return
+	}
+
+	if len(node.Content) == 0 {
+		// Empty mappings are written in flow style ("{}"), so switch
+		// to block style when adding to one.
+		node.Style &^= yaml.FlowStyle
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: path[0]}
+	if len(path) > 1 {
+		child := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		setNode(child, path[1:], value)
+		value = child
+	}
+	node.Content = append(node.Content, key, value)
+}
+
+// unsetNode removes the key at path from the mapping node.
+// Returns true if it was removed.
+func unsetNode(node *yaml.Node, path []string) bool {
+	parent := findNode(node, path[:len(path)-1])
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return false
+	}
+	name := path[len(path)-1]
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == name {
+			parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
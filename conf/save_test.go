@@ -0,0 +1,205 @@
+package conf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prashantv/gostub" // spell: disable-line
+	"github.com/stretchr/testify/assert"
+)
+
+type SaveConfig struct {
+	Name     string `default:"untitled"`
+	Count    int    `default:"1" validate:"lt=100"`
+	Database struct {
+		URL string `yaml:"url"`
+	} `yaml:"database"`
+	Labels map[string]string
+	Tags   []string
+}
+
+const saveFixture = `---
+# The app name
+name: aaa # inline
+count: 10
+
+# Tags
+tags:
+  - foo
+extra: kept
+`
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func TestLoader_Get(t *testing.T) {
+	loader := NewLoader(&SaveConfig{}, writeFixture(t, "config.yaml", saveFixture))
+
+	value, err := loader.Get("name")
+	assert.NoError(t, err)
+	assert.Equal(t, "aaa", value)
+
+	value, err = loader.Get("tags")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"foo"}, value)
+
+	value, err = loader.Get("labels.team")
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+
+	_, err = loader.Get("nope")
+	assert.ErrorContains(t, err, "unknown config key: nope")
+	_, err = loader.Get("labels.team.nope")
+	assert.ErrorContains(t, err, "unknown config key: labels.team.nope")
+}
+
+func TestLoader_Set(t *testing.T) {
+	path := writeFixture(t, "config.yaml", saveFixture)
+	loader := NewLoader(&SaveConfig{}, path)
+	config, err := loader.Load()
+	assert.NoError(t, err)
+
+	assert.NoError(t, loader.Set("count", "20"))
+	assert.NoError(t, loader.Set("name", "10"))
+	assert.NoError(t, loader.Set("database.url", "postgres://db"))
+	assert.NoError(t, loader.Set("labels.team", "core"))
+	assert.NoError(t, loader.Set("tags", "[foo, bar]"))
+
+	assert.Equal(t, `---
+# The app name
+name: "10" # inline
+count: 20
+# Tags
+tags:
+  - foo
+  - bar
+extra: kept
+database:
+  url: postgres://db
+labels:
+  team: core
+`, readFile(t, path))
+
+	// Existing references should not be modified.
+	assert.Equal(t, 10, config.Count)
+	assert.Equal(t, "aaa", config.Name)
+	assert.Nil(t, config.Labels)
+
+	current := loader.Current()
+	assert.Equal(t, 20, current.Count)
+	assert.Equal(t, "10", current.Name)
+	assert.Equal(t, map[string]string{"team": "core"}, current.Labels)
+	origin, _ := loader.Origin("count")
+	assert.Equal(t, LayerUser, origin.Layer)
+
+	info, _ := os.Stat(path)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestLoader_Set_Errors(t *testing.T) {
+	path := writeFixture(t, "config.yaml", saveFixture)
+	loader := NewLoader(&SaveConfig{}, path)
+
+	err := loader.Set("nope", "1")
+	assert.ErrorContains(t, err, "unknown config key: nope")
+
+	err = loader.Set("count", "abc")
+	assert.ErrorContains(t, err, "invalid value for count")
+
+	err = loader.Set("count", "999")
+	assert.ErrorContains(t, err, "Count must be less than 100")
+
+	// File should be untouched.
+	assert.Equal(t, saveFixture, readFile(t, path))
+
+	err = NewLoader(&SaveConfig{}, "").Set("count", "1")
+	assert.ErrorContains(t, err, "config path not set")
+
+	err = NewLoader(&SaveConfig{}, FixturePath("config.toml")).Set("count", "1")
+	assert.ErrorContains(t, err, "unable to write toml config file")
+
+	invalid := writeFixture(t, "config.yaml", "- a\n- b\n")
+	err = NewLoader(&SaveConfig{}, invalid).Set("count", "1")
+	assert.ErrorContains(t, err, "expected a mapping")
+}
+
+func TestLoader_Set_WhenWriteError(t *testing.T) {
+	stubs := gostub.StubFunc(&writeFileAtomic, errors.New("boom"))
+	defer stubs.Reset()
+
+	err := NewLoader(&SaveConfig{}, writeFixture(t, "config.yaml", saveFixture)).Set("count", "1")
+	assert.ErrorContains(t, err, "boom")
+}
+
+func TestLoader_Set_WhenMissingDir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "my-app", "config.yaml")
+	loader := NewLoader(&SaveConfig{}, path)
+	assert.NoError(t, loader.Set("count", "2"))
+	assert.Equal(t, "count: 2\n", readFile(t, path))
+
+	info, _ := os.Stat(filepath.Dir(path))
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+}
+
+func TestLoader_Unset(t *testing.T) {
+	path := writeFixture(t, "config.yaml", saveFixture)
+	loader := NewLoader(&SaveConfig{}, path)
+	config, _ := loader.Load()
+
+	assert.NoError(t, loader.Unset("name"))
+	assert.NoError(t, loader.Unset("database.url"))
+	assert.NoError(t, loader.Unset("labels.team"))
+
+	assert.Equal(t, `---
+count: 10
+# Tags
+tags:
+  - foo
+extra: kept
+`, readFile(t, path))
+	assert.Equal(t, "aaa", config.Name)
+	assert.Equal(t, "untitled", loader.Current().Name)
+
+	assert.ErrorContains(t, loader.Unset("nope"), "unknown config key: nope")
+}
+
+func TestLoader_Save(t *testing.T) {
+	path := writeFixture(t, "config.yaml", saveFixture)
+	t.Setenv("SAVE_CONFIG_URL", "postgres://env")
+	type EnvConfig struct {
+		SaveConfig `yaml:",inline"`
+		URL        string `yaml:"url" env:"SAVE_CONFIG_URL"`
+	}
+	loader := NewLoader(&EnvConfig{}, path)
+	config, err := loader.Load()
+	assert.NoError(t, err)
+
+	config.Count = 11
+	config.Labels = map[string]string{"team": "core"}
+	assert.NoError(t, loader.Save())
+
+	// Values equal to the defaults and values from env vars are skipped.
+	assert.Equal(t, `---
+# The app name
+name: aaa # inline
+count: 11
+# Tags
+tags:
+  - foo
+extra: kept
+labels:
+  team: core
+`, readFile(t, path))
+
+	// Saving creates a new config struct.
+	assert.NotSame(t, config, loader.Current())
+	config = loader.Current()
+	config.Count = 999
+	assert.ErrorContains(t, loader.Save(), "Count must be less than 100")
+}
@@ -0,0 +1,522 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// JSONSchemaVersion is the JSON Schema dialect used by [Loader.JSONSchema].
+const JSONSchemaVersion = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema returns a JSON Schema document describing the config file,
+// generated from the config struct tags:
+//
+//   - `yaml`: the property names
+//   - `usage`: the property descriptions
+//   - `default`: the property defaults
+//   - `env`: the env var names (as the "x-env" extension keyword)
+//   - `validate`: constraints such as min/max, oneof, url, and email
+//
+// Properties are never marked as required, since a value may be set
+// in any of the config layers (or via env var).
+// Unknown properties are not allowed, other than the reserved keys:
+// [VersionKey] when migrations are enabled (see [WithMigrations]), and
+// [ProfilesKey] and [CurrentProfileKey] when profiles are enabled
+// (see [WithProfiles]).
+func (l *Loader[C]) JSONSchema() ([]byte, error) {
+	fields, err := l.docFields()
+	if err != nil {
+		return nil, err
+	}
+	schema := objectSchema(fields)
+	schema.Schema = JSONSchemaVersion
+	if n := len(l.opts.migrations); n > 0 {
+		minimum, maximum := float64(0), float64(n)
+		schema.Properties.set(VersionKey, &jsonSchema{
+			Description: "Config file version (the number of migrations applied)",
+			Type:        "integer",
+			Minimum:     &minimum,
+			Maximum:     &maximum,
+		})
+	}
+	if l.opts.profiles {
+		schema.Properties.set(CurrentProfileKey, &jsonSchema{
+			Description: "Current profile",
+			Type:        "string",
+		})
+		schema.Properties.set(ProfilesKey, &jsonSchema{
+			Description:          "Named profiles (overriding the top-level values)",
+			Type:                 "object",
+			AdditionalProperties: objectSchema(fields),
+		})
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// MarkdownReference returns a Markdown table documenting each config key,
+// its type, default value, env var, validation rules, and usage.
+func (l *Loader[C]) MarkdownReference() (string, error) {
+	fields, err := l.docFields()
+	if err != nil {
+		return "", err
+	}
+	buf := &bytes.Buffer{}
+	buf.WriteString("| Key | Type | Default | Env | Validation | Description |\n")
+	buf.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for _, f := range leafDocFields(fields) {
+		fmt.Fprintf(buf, "| %s | %s | %s | %s | %s | %s |\n",
+			markdownCode(f.Key),
+			typeName(f.Field.Type),
+			markdownCode(f.defaultString()),
+			markdownCode(f.EnvVar),
+			markdownCode(f.Field.Tag.Get("validate")),
+			markdownEscape(f.Field.Tag.Get("usage")),
+		)
+	}
+	return buf.String(), nil
+}
+
+// ExampleConfig returns an example YAML config file containing every key
+// set to its default value. Each key is preceded by a comment describing
+// its usage, type, env var, and validation rules.
+func (l *Loader[C]) ExampleConfig() ([]byte, error) {
+	fields, err := l.docFields()
+	if err != nil {
+		return nil, err
+	}
+	node, err := exampleNode(fields)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return separateComments(buf.Bytes()), nil
+}
+
+// separateComments adds a blank line before each comment block
+// (other than those that start a mapping) for readability.
+func separateComments(data []byte) []byte {
+	lines := strings.Split(string(data), "\n")
+	out := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if i > 0 && strings.HasPrefix(strings.TrimSpace(line), "#") {
+			prev := strings.TrimSpace(lines[i-1])
+			if !strings.HasPrefix(prev, "#") && !strings.HasSuffix(prev, ":") {
+				out = append(out, "")
+			}
+		}
+		out = append(out, line)
+	}
+	return []byte(strings.Join(out, "\n"))
+}
+
+// docField is a field in the config struct used to generate docs.
+type docField struct {
+	configField
+	// Name is the YAML key within the parent mapping.
+	Name string
+	// Children are the fields of a nested struct.
+	Children []*docField
+	// Default is the default value of a leaf field (invalid if unset).
+	Default reflect.Value
+}
+
+// docFields returns the field tree for the config struct,
+// including the default values for each leaf field.
+func (l *Loader[C]) docFields() ([]*docField, error) {
+	config := l.newConfig()
+	if err := defaultsSet(config); err != nil {
+		return nil, err
+	}
+	defaults := map[string]reflect.Value{}
+	for _, f := range configFields(reflect.ValueOf(config)) {
+		defaults[f.Key] = f.Value
+	}
+	return walkDocFields(reflect.TypeOf(config), "", l.envScope(), defaults), nil
+}
+
+func walkDocFields(t reflect.Type, keyPrefix string, scope envScope, defaults map[string]reflect.Value) []*docField {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	fields := []*docField{}
+	if t.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() && !sf.Anonymous {
+			continue
+		}
+		name, inline, skip := yamlFieldName(sf)
+		if skip {
+			continue
+		}
+		if inline {
+			fields = append(fields, walkDocFields(sf.Type, keyPrefix, scope, defaults)...)
+			continue
+		}
+		f := &docField{
+			configField: configField{
+				Key:    keyPrefix + name,
+				EnvVar: scope.name(sf),
+				Flag:   sf.Tag.Get("flag"),
+				Field:  sf,
+			},
+			Name: name,
+		}
+		if isBranch(sf.Type) {
+			f.Children = walkDocFields(sf.Type, f.Key+".", scope.nested(sf), defaults)
+		} else if v, ok := defaults[f.Key]; ok && !v.IsZero() {
+			f.Default = v
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// leafDocFields returns the leaf fields in the tree, depth first.
+func leafDocFields(fields []*docField) []*docField {
+	leaves := []*docField{}
+	for _, f := range fields {
+		if f.Children != nil {
+			leaves = append(leaves, leafDocFields(f.Children)...)
+		} else {
+			leaves = append(leaves, f)
+		}
+	}
+	return leaves
+}
+
+// defaultValue returns the default in a form suitable for JSON and YAML.
+func (f *docField) defaultValue() any {
+	if !f.Default.IsValid() {
+		return nil
+	}
+	if f.Field.Type == durationType {
+		return fmt.Sprint(f.Default.Interface())
+	}
+	return f.Default.Interface()
+}
+
+// defaultString returns the default formatted as it would be in a flag.
+func (f *docField) defaultString() string {
+	if !f.Default.IsValid() {
+		return ""
+	}
+	return formatFlagValue(f.Default)
+}
+
+// jsonSchema is a (subset of a) JSON Schema document.
+type jsonSchema struct {
+	Schema               string            `json:"$schema,omitempty"`
+	Description          string            `json:"description,omitempty"`
+	Type                 string            `json:"type,omitempty"`
+	Format               string            `json:"format,omitempty"`
+	Properties           *schemaProperties `json:"properties,omitempty"`
+	AdditionalProperties any               `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema       `json:"items,omitempty"`
+	Enum                 []any             `json:"enum,omitempty"`
+	Default              any               `json:"default,omitempty"`
+	Minimum              *float64          `json:"minimum,omitempty"`
+	Maximum              *float64          `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64          `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64          `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int              `json:"minLength,omitempty"`
+	MaxLength            *int              `json:"maxLength,omitempty"`
+	MinItems             *int              `json:"minItems,omitempty"`
+	MaxItems             *int              `json:"maxItems,omitempty"`
+	Env                  string            `json:"x-env,omitempty"`
+}
+
+// schemaProperties are the properties of an object schema,
+// marshalled in struct field order (rather than sorted).
+type schemaProperties struct {
+	names   []string
+	schemas map[string]*jsonSchema
+}
+
+// set sets the schema for the named property
+// (appending it to the property order if new).
+func (p *schemaProperties) set(name string, schema *jsonSchema) {
+	if _, ok := p.schemas[name]; !ok {
+		p.names = append(p.names, name)
+	}
+	p.schemas[name] = schema
+}
+
+func (p *schemaProperties) MarshalJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString("{")
+	for i, name := range p.names {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		key, _ := json.Marshal(name)
+		value, err := json.Marshal(p.schemas[name])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(value)
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+// objectSchema returns the schema for a struct with the given fields.
+func objectSchema(fields []*docField) *jsonSchema {
+	props := &schemaProperties{schemas: map[string]*jsonSchema{}}
+	for _, f := range fields {
+		var schema *jsonSchema
+		if f.Children != nil {
+			schema = objectSchema(f.Children)
+		} else {
+			schema = typeSchema(f.Field.Type)
+			schema.Default = f.defaultValue()
+			schema.Env = f.EnvVar
+			applyValidateRules(schema, f.Field.Tag.Get("validate"))
+		}
+		schema.Description = f.Field.Tag.Get("usage")
+		props.set(f.Name, schema)
+	}
+	return &jsonSchema{
+		Type:                 "object",
+		Properties:           props,
+		AdditionalProperties: false,
+	}
+}
+
+// typeSchema returns the schema for values of type t.
+func typeSchema(t reflect.Type) *jsonSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == durationType:
+		return &jsonSchema{Type: "string", Format: "duration"}
+	case t == timeType:
+		return &jsonSchema{Type: "string", Format: "date-time"}
+	case reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return &jsonSchema{Type: "string"}
+	case isBranch(t):
+		return objectSchema(walkDocFields(t, "", envScope{}, nil))
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &jsonSchema{Type: "array", Items: typeSchema(t.Elem())}
+	case reflect.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: typeSchema(t.Elem())}
+	default:
+		return &jsonSchema{}
+	}
+}
+
+// applyValidateRules adds the constraints from a `validate` tag to schema.
+// Rules that have no JSON Schema equivalent are ignored,
+// as are any rules that apply to slice or map elements (after "dive").
+func applyValidateRules(schema *jsonSchema, rules string) {
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		if name == "dive" {
+			return
+		}
+		switch name {
+		case "oneof":
+			for _, item := range strings.Fields(param) {
+				schema.Enum = append(schema.Enum, schemaValue(schema.Type, item))
+			}
+		case "url", "uri":
+			schema.Format = "uri"
+		case "email":
+			schema.Format = "email"
+		case "hostname":
+			schema.Format = "hostname"
+		case "ipv4", "ipv6":
+			schema.Format = name
+		case "min", "gte", "max", "lte", "gt", "lt", "len":
+			applyBound(schema, name, param)
+		}
+	}
+}
+
+// applyBound adds a numeric, length, or item count constraint to schema.
+func applyBound(schema *jsonSchema, name string, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	switch schema.Type {
+	case "integer", "number":
+		switch name {
+		case "min", "gte":
+			schema.Minimum = &n
+		case "max", "lte":
+			schema.Maximum = &n
+		case "gt":
+			schema.ExclusiveMinimum = &n
+		case "lt":
+			schema.ExclusiveMaximum = &n
+		case "len":
+			schema.Minimum, schema.Maximum = &n, &n
+		}
+	case "string", "array":
+		size := int(n)
+		minimum, maximum := &schema.MinLength, &schema.MaxLength
+		if schema.Type == "array" {
+			minimum, maximum = &schema.MinItems, &schema.MaxItems
+		}
+		switch name {
+		case "min", "gte":
+			*minimum = &size
+		case "max", "lte":
+			*maximum = &size
+		case "gt":
+			size++
+			*minimum = &size
+		case "lt":
+			size--
+			*maximum = &size
+		case "len":
+			*minimum, *maximum = &size, &size
+		}
+	}
+}
+
+// schemaValue converts s to a value of the JSON Schema type (if possible).
+func schemaValue(typ string, s string) any {
+	switch typ {
+	case "integer":
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+	return s
+}
+
+// typeName returns a human readable name for type t (i.e. "list of string").
+func typeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == durationType:
+		return "duration"
+	case t == timeType:
+		return "timestamp"
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return "list of " + typeName(t.Elem())
+	case t.Kind() == reflect.Map:
+		return "map of " + typeName(t.Elem())
+	case reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return "string"
+	}
+	schema := typeSchema(t)
+	if schema.Type == "" {
+		return "any"
+	}
+	return schema.Type
+}
+
+// exampleNode returns a YAML mapping node for fields, set to their defaults.
+func exampleNode(fields []*docField) (*yaml.Node, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, f := range fields {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: f.Name}
+		var value *yaml.Node
+		if f.Children != nil {
+			var err error
+			if value, err = exampleNode(f.Children); err != nil {
+				return nil, err
+			}
+			key.HeadComment = f.Field.Tag.Get("usage")
+		} else {
+			value = &yaml.Node{}
+			if err := value.Encode(f.exampleValue()); err != nil {
+				return nil, err
+			}
+			key.HeadComment = f.exampleComment()
+		}
+		node.Content = append(node.Content, key, value)
+	}
+	return node, nil
+}
+
+// exampleValue returns the default, or an empty value of the field type.
+func (f *docField) exampleValue() any {
+	if v := f.defaultValue(); v != nil {
+		return v
+	}
+	switch schema := typeSchema(f.Field.Type); schema.Type {
+	case "array":
+		return []any{}
+	case "object":
+		return map[string]any{}
+	case "string":
+		return ""
+	default:
+		return reflect.Zero(f.Field.Type).Interface()
+	}
+}
+
+// exampleComment returns the comment describing a leaf field.
+func (f *docField) exampleComment() string {
+	lines := []string{}
+	if usage := f.Field.Tag.Get("usage"); usage != "" {
+		lines = append(lines, usage)
+	}
+	details := []string{"Type: " + typeName(f.Field.Type)}
+	if f.EnvVar != "" {
+		details = append(details, "Env: "+f.EnvVar)
+	}
+	if rules := f.Field.Tag.Get("validate"); rules != "" {
+		details = append(details, "Validation: "+rules)
+	}
+	lines = append(lines, strings.Join(details, ", "))
+	return strings.Join(lines, "\n")
+}
+
+// markdownCode formats s as inline code (or returns "" if empty).
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + markdownEscape(s) + "`"
+}
+
+// markdownEscape escapes s for use in a Markdown table cell.
+func markdownEscape(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
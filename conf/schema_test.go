@@ -0,0 +1,243 @@
+package conf
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prashantv/gostub" // spell: disable-line
+	"github.com/stretchr/testify/assert"
+)
+
+type SchemaConfig struct {
+	BaseURL  string        `yaml:"base_url" usage:"API URL" default:"https://a.io" env:"APP_URL" validate:"required,url"`
+	LogLevel string        `yaml:"log_level" usage:"Log level" default:"info" validate:"oneof=debug info warn"`
+	Retries  int           `usage:"Max retries" default:"3" validate:"gte=0,lt=10"`
+	Timeout  time.Duration `usage:"Request timeout" default:"5s"`
+	Tags     []string      `usage:"Tags | labels" validate:"max=5,dive,required"`
+	Labels   map[string]string
+	Database struct {
+		URL  string `yaml:"url" usage:"Database URL" env:"URL" validate:"required"`
+		Pool int    `yaml:"pool" default:"5" validate:"min=1"`
+	} `yaml:"database" usage:"Database settings" envPrefix:"APP_DB_"`
+	Ignored string `yaml:"-"`
+}
+
+func TestLoader_JSONSchema(t *testing.T) {
+	loader := NewLoader(&SchemaConfig{}, "")
+	data, err := loader.JSONSchema()
+	assert.NoError(t, err)
+
+	schema := map[string]any{}
+	assert.NoError(t, json.Unmarshal(data, &schema))
+	assert.Equal(t, JSONSchemaVersion, schema["$schema"])
+	assert.Equal(t, false, schema["additionalProperties"])
+
+	props := schema["properties"].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"description": "API URL",
+		"type":        "string",
+		"format":      "uri",
+		"default":     "https://a.io",
+		"x-env":       "APP_URL",
+	}, props["base_url"])
+	assert.Equal(t, map[string]any{
+		"description": "Log level",
+		"type":        "string",
+		"enum":        []any{"debug", "info", "warn"},
+		"default":     "info",
+	}, props["log_level"])
+	assert.Equal(t, map[string]any{
+		"description":      "Max retries",
+		"type":             "integer",
+		"default":          float64(3),
+		"minimum":          float64(0),
+		"exclusiveMaximum": float64(10),
+	}, props["retries"])
+	assert.Equal(t, map[string]any{
+		"description": "Request timeout",
+		"type":        "string",
+		"format":      "duration",
+		"default":     "5s",
+	}, props["timeout"])
+	assert.Equal(t, map[string]any{
+		"description": "Tags | labels",
+		"type":        "array",
+		"items":       map[string]any{"type": "string"},
+		"maxItems":    float64(5),
+	}, props["tags"])
+	assert.Equal(t, map[string]any{
+		"type":                 "object",
+		"additionalProperties": map[string]any{"type": "string"},
+	}, props["labels"])
+	assert.Equal(t, map[string]any{
+		"description": "Database settings",
+		"type":        "object",
+		"properties": map[string]any{
+			"url": map[string]any{
+				"description": "Database URL",
+				"type":        "string",
+				"x-env":       "APP_DB_URL",
+			},
+			"pool": map[string]any{
+				"type":    "integer",
+				"default": float64(5),
+				"minimum": float64(1),
+			},
+		},
+		"additionalProperties": false,
+	}, props["database"])
+	assert.NotContains(t, props, "ignored")
+
+	// Properties are in struct field order.
+	assert.Regexp(t, `(?s)"base_url".*"log_level".*"retries".*"timeout".*"tags".*"labels".*"database"`, string(data))
+}
+
+func TestLoader_JSONSchema_WhenMigrations(t *testing.T) {
+	loader := NewLoader(&SchemaConfig{}, "", WithMigrations(RenameKey("url", "base_url"), RemoveKey("legacy")))
+	data, err := loader.JSONSchema()
+	assert.NoError(t, err)
+
+	schema := map[string]any{}
+	assert.NoError(t, json.Unmarshal(data, &schema))
+	props := schema["properties"].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"description": "Config file version (the number of migrations applied)",
+		"type":        "integer",
+		"minimum":     float64(0),
+		"maximum":     float64(2),
+	}, props[VersionKey])
+
+	// Not allowed unless migrations are enabled.
+	data, err = NewLoader(&SchemaConfig{}, "").JSONSchema()
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), `"version"`)
+}
+
+func TestLoader_JSONSchema_WhenProfiles(t *testing.T) {
+	loader := NewLoader(&SchemaConfig{}, "", WithProfiles("APP_PROFILE"))
+	data, err := loader.JSONSchema()
+	assert.NoError(t, err)
+
+	schema := map[string]any{}
+	assert.NoError(t, json.Unmarshal(data, &schema))
+	props := schema["properties"].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"description": "Current profile",
+		"type":        "string",
+	}, props[CurrentProfileKey])
+
+	profiles := props[ProfilesKey].(map[string]any)
+	assert.Equal(t, "object", profiles["type"])
+	profile := profiles["additionalProperties"].(map[string]any)
+	assert.Equal(t, false, profile["additionalProperties"])
+	assert.Contains(t, profile["properties"], "base_url")
+	assert.NotContains(t, profile["properties"], ProfilesKey)
+
+	// Not allowed unless profiles are enabled.
+	data, err = NewLoader(&SchemaConfig{}, "").JSONSchema()
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), `"profiles"`)
+	assert.NotContains(t, string(data), `"current_profile"`)
+}
+
+func TestLoader_MarkdownReference(t *testing.T) {
+	loader := NewLoader(&SchemaConfig{}, "")
+	ref, err := loader.MarkdownReference()
+	assert.NoError(t, err)
+	assert.Equal(t, "| Key | Type | Default | Env | Validation | Description |\n"+
+		"| --- | --- | --- | --- | --- | --- |\n"+
+		"| `base_url` | string | `https://a.io` | `APP_URL` | `required,url` | API URL |\n"+
+		"| `log_level` | string | `info` |  | `oneof=debug info warn` | Log level |\n"+
+		"| `retries` | integer | `3` |  | `gte=0,lt=10` | Max retries |\n"+
+		"| `timeout` | duration | `5s` |  |  | Request timeout |\n"+
+		"| `tags` | list of string |  |  | `max=5,dive,required` | Tags \\| labels |\n"+
+		"| `labels` | map of string |  |  |  |  |\n"+
+		"| `database.url` | string |  | `APP_DB_URL` | `required` | Database URL |\n"+
+		"| `database.pool` | integer | `5` |  | `min=1` |  |\n", ref)
+}
+
+func TestLoader_ExampleConfig(t *testing.T) {
+	loader := NewLoader(&SchemaConfig{}, "")
+	data, err := loader.ExampleConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, `# API URL
+# Type: string, Env: APP_URL, Validation: required,url
+base_url: https://a.io
+
+# Log level
+# Type: string, Validation: oneof=debug info warn
+log_level: info
+
+# Max retries
+# Type: integer, Validation: gte=0,lt=10
+retries: 3
+
+# Request timeout
+# Type: duration
+timeout: 5s
+
+# Tags | labels
+# Type: list of string, Validation: max=5,dive,required
+tags: []
+
+# Type: map of string
+labels: {}
+
+# Database settings
+database:
+  # Database URL
+  # Type: string, Env: APP_DB_URL, Validation: required
+  url: ""
+
+  # Type: integer, Validation: min=1
+  pool: 5
+`, string(data))
+
+	// The example can be decoded as a config file.
+	config := &SchemaConfig{}
+	assert.NoError(t, yamlUnmarshal(data, config))
+	assert.Equal(t, 5*time.Second, config.Timeout)
+	assert.Equal(t, 5, config.Database.Pool)
+}
+
+func TestLoader_Schema_WhenDefaultsError(t *testing.T) {
+	stubs := gostub.StubFunc(&defaultsSet, errors.New("boom"))
+	defer stubs.Reset()
+
+	loader := NewLoader(&SchemaConfig{}, "")
+	_, err := loader.JSONSchema()
+	assert.ErrorContains(t, err, "boom")
+	_, err = loader.MarkdownReference()
+	assert.ErrorContains(t, err, "boom")
+	_, err = loader.ExampleConfig()
+	assert.ErrorContains(t, err, "boom")
+}
+
+func TestApplyValidateRules(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+	floatPtr := func(f float64) *float64 { return &f }
+	tests := []struct {
+		typ   string
+		rules string
+		want  *jsonSchema
+	}{
+		{"string", "min=1,max=10", &jsonSchema{Type: "string", MinLength: intPtr(1), MaxLength: intPtr(10)}},
+		{"string", "gt=1,lt=10", &jsonSchema{Type: "string", MinLength: intPtr(2), MaxLength: intPtr(9)}},
+		{"string", "len=3,email", &jsonSchema{Type: "string", MinLength: intPtr(3), MaxLength: intPtr(3), Format: "email"}},
+		{"array", "gte=1,lte=2", &jsonSchema{Type: "array", MinItems: intPtr(1), MaxItems: intPtr(2)}},
+		{"number", "gt=0.5,lte=1", &jsonSchema{Type: "number", ExclusiveMinimum: floatPtr(0.5), Maximum: floatPtr(1)}},
+		{"integer", "oneof=1 2", &jsonSchema{Type: "integer", Enum: []any{int64(1), int64(2)}}},
+		{"integer", "min=abc", &jsonSchema{Type: "integer"}},
+		{"string", "hostname,required", &jsonSchema{Type: "string", Format: "hostname"}},
+		{"array", "dive,min=1", &jsonSchema{Type: "array"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ+":"+tt.rules, func(t *testing.T) {
+			schema := &jsonSchema{Type: tt.typ}
+			applyValidateRules(schema, tt.rules)
+			assert.Equal(t, tt.want, schema)
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package conf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/twelvelabs/termite/fsutil"
+	"github.com/twelvelabs/termite/run"
+	"github.com/twelvelabs/termite/validate"
+)
+
+// Secret reference prefixes.
+const (
+	SecretEnv  = "env:"
+	SecretFile = "file:"
+	SecretCmd  = "cmd:"
+)
+
+var (
+	secretType = reflect.TypeOf(Secret{})
+)
+
+func init() {
+	// Validate the reference rather than the (resolved) secret,
+	// so that validation never triggers resolution.
+	validate.RegisterCustomTypeFunc(func(v reflect.Value) any {
+		return v.Interface().(Secret).ref
+	}, Secret{})
+}
+
+// NewSecret returns a new [Secret] for ref.
+func NewSecret(ref string) Secret {
+	return Secret{ref: ref, state: &secretState{}}
+}
+
+// Secret is a config value that should not be stored in plain text.
+// Its value in the config file is a reference to where the secret is kept:
+//
+//   - "env:VAR": the value of the env var VAR
+//   - "file:/path": the contents of the file (trailing newlines removed)
+//   - "cmd:pass show x": the output of the shell command (trailing newlines removed)
+//
+// Any other value is used as is.
+//
+// References are resolved lazily, the first time [Secret.Value] is called,
+// and the result is cached. Commands are run via the [run.Client] set
+// with [WithSecretClient].
+//
+// Secrets are always redacted when formatted or marshalled,
+// and validation rules apply to the reference (not the resolved value).
+type Secret struct {
+	ref   string
+	state *secretState
+}
+
+type secretState struct {
+	mu       sync.Mutex
+	client   *run.Client
+	resolved bool
+	value    string
+}
+
+// Ref returns the secret reference (i.e. "env:API_TOKEN").
+func (s Secret) Ref() string {
+	return s.ref
+}
+
+// IsSet returns true if the secret has a reference.
+func (s Secret) IsSet() bool {
+	return s.ref != ""
+}
+
+// Value resolves and returns the secret value.
+func (s Secret) Value() (string, error) {
+	if s.state == nil {
+		return resolveSecret(nil, s.ref)
+	}
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+	if s.state.resolved {
+		return s.state.value, nil
+	}
+	value, err := resolveSecret(s.state.client, s.ref)
+	if err != nil {
+		return "", err
+	}
+	s.state.value = value
+	s.state.resolved = true
+	return value, nil
+}
+
+// String returns [run.Redacted] (or an empty string if not set).
+func (s Secret) String() string {
+	if !s.IsSet() {
+		return ""
+	}
+	return run.Redacted
+}
+
+// GoString returns the redacted secret (for the `%#v` verb).
+func (s Secret) GoString() string {
+	return fmt.Sprintf("conf.Secret(%q)", s.String())
+}
+
+// MarshalText returns the redacted secret.
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// MarshalJSON returns the redacted secret.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// MarshalYAML returns the redacted secret.
+func (s Secret) MarshalYAML() (any, error) {
+	return s.String(), nil
+}
+
+// UnmarshalText sets the secret reference (i.e. from an env var or default).
+func (s *Secret) UnmarshalText(text []byte) error {
+	*s = NewSecret(string(text))
+	return nil
+}
+
+// UnmarshalYAML sets the secret reference from a config file.
+func (s *Secret) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: secret must be a string", node.Line)
+	}
+	*s = NewSecret(node.Value)
+	return nil
+}
+
+// bind sets the client used to resolve command references.
+func (s *Secret) bind(client *run.Client) {
+	if s.state == nil {
+		s.state = &secretState{}
+	}
+	s.state.client = client
+}
+
+// bindSecrets sets the client for each secret in the config struct.
+func bindSecrets(config any, client *run.Client) {
+	for _, f := range configFields(reflect.ValueOf(config)) {
+		if f.Field.Type == secretType && f.Value.CanAddr() {
+			f.Value.Addr().Interface().(*Secret).bind(client)
+		}
+	}
+}
+
+// resolveSecret returns the value referenced by ref.
+func resolveSecret(client *run.Client, ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, SecretEnv):
+		name := strings.TrimPrefix(ref, SecretEnv)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("secret env var not set: %s", name)
+		}
+		return value, nil
+	case strings.HasPrefix(ref, SecretFile):
+		path, err := fsutil.NormalizePath(strings.TrimPrefix(ref, SecretFile))
+		if err != nil {
+			return "", err
+		}
+		data, err := osReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("unable to read secret file: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case strings.HasPrefix(ref, SecretCmd):
+		if client == nil {
+			client = run.NewClient()
+		}
+		command := strings.TrimPrefix(ref, SecretCmd)
+		if strings.TrimSpace(command) == "" {
+			return "", errors.New("secret command is empty")
+		}
+		out, err := client.Command("sh", "-c", command).Output()
+		if err != nil {
+			return "", fmt.Errorf("secret command failed: %w", err)
+		}
+		return strings.TrimRight(string(out), "\r\n"), nil
+	default:
+		return ref, nil
+	}
+}
+
+// persistValue returns v in the form it should be written to a config file.
+// Secrets are written as their reference (rather than redacted).
+func persistValue(v reflect.Value) any {
+	if s, ok := v.Interface().(Secret); ok {
+		return s.Ref()
+	}
+	return v.Interface()
+}
@@ -0,0 +1,178 @@
+package conf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/twelvelabs/termite/run"
+)
+
+type SecretConfig struct {
+	Name     string
+	APIToken Secret `yaml:"api_token" env:"SECRET_API_TOKEN" validate:"required"`
+	Password Secret `yaml:"password" default:"env:SECRET_PASSWORD"`
+}
+
+func matchSecretCmd(command string) run.Matcher {
+	return run.MatchAll(run.MatchName("sh"), run.MatchArgvSubsequence("-c", command))
+}
+
+func TestSecret_Value(t *testing.T) {
+	t.Setenv("SECRET_VAR", "from-env")
+	path := writeFixture(t, "token", "from-file\n")
+
+	tests := []struct {
+		desc string
+		ref  string
+		want string
+		err  string
+	}{
+		{desc: "empty", ref: "", want: ""},
+		{desc: "literal", ref: "plain-text", want: "plain-text"},
+		{desc: "literal with colon", ref: "https://example.com", want: "https://example.com"},
+		{desc: "env", ref: "env:SECRET_VAR", want: "from-env"},
+		{desc: "env when unset", ref: "env:SECRET_UNSET", err: "secret env var not set: SECRET_UNSET"},
+		{desc: "file", ref: "file:" + path, want: "from-file"},
+		{desc: "file when missing", ref: "file:" + path + ".missing", err: "unable to read secret file"},
+		{desc: "cmd when empty", ref: "cmd: ", err: "secret command is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			value, err := NewSecret(tt.ref).Value()
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.err)
+			}
+			assert.Equal(t, tt.want, value)
+		})
+	}
+}
+
+func TestSecret_Value_Cmd(t *testing.T) {
+	client := run.NewClient().WithStubbing()
+	defer client.VerifyStubs(t)
+	client.RegisterStub(
+		matchSecretCmd("pass show api/token"),
+		run.StringResponse("from-cmd\n"),
+	)
+
+	secret := NewSecret("cmd:pass show api/token")
+	secret.bind(client)
+
+	// Resolved once, then cached (the stub only matches once).
+	for i := 0; i < 2; i++ {
+		value, err := secret.Value()
+		assert.NoError(t, err)
+		assert.Equal(t, "from-cmd", value)
+	}
+}
+
+func TestSecret_Value_CmdError(t *testing.T) {
+	client := run.NewClient().WithStubbing()
+	defer client.VerifyStubs(t)
+	client.RegisterStub(
+		matchSecretCmd("pass show api/token"),
+		run.ErrorResponse(errors.New("boom")),
+	)
+
+	secret := NewSecret("cmd:pass show api/token")
+	secret.bind(client)
+	value, err := secret.Value()
+	assert.ErrorContains(t, err, "secret command failed: boom")
+	assert.Equal(t, "", value)
+}
+
+func TestSecret_Redacted(t *testing.T) {
+	secret := NewSecret("hunter2")
+	config := &SecretConfig{Name: "aaa", APIToken: secret}
+
+	assert.Equal(t, run.Redacted, secret.String())
+	assert.Equal(t, `conf.Secret("[REDACTED]")`, fmt.Sprintf("%#v", secret))
+	assert.NotContains(t, fmt.Sprintf("%v %+v", config, config), "hunter2")
+
+	data, err := json.Marshal(config)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"Name":"aaa","APIToken":"[REDACTED]","Password":""}`, string(data))
+
+	data, err = yaml.Marshal(config)
+	assert.NoError(t, err)
+	assert.Equal(t, "name: aaa\napi_token: '[REDACTED]'\npassword: \"\"\n", string(data))
+
+	text, err := secret.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, run.Redacted, string(text))
+
+	assert.Equal(t, "", Secret{}.String())
+}
+
+func TestSecret_UnmarshalYAML(t *testing.T) {
+	config := &SecretConfig{}
+	err := yaml.Unmarshal([]byte("api_token: env:API_TOKEN\n"), config)
+	assert.NoError(t, err)
+	assert.Equal(t, "env:API_TOKEN", config.APIToken.Ref())
+	assert.True(t, config.APIToken.IsSet())
+
+	err = yaml.Unmarshal([]byte("api_token: [a, b]\n"), config)
+	assert.ErrorContains(t, err, "line 1: secret must be a string")
+}
+
+func TestLoader_Secrets(t *testing.T) {
+	t.Setenv("SECRET_PASSWORD", "from-env")
+	path := writeFixture(t, "config.yaml", "api_token: cmd:pass show api/token\n")
+
+	client := run.NewClient().WithStubbing()
+	defer client.VerifyStubs(t)
+	client.RegisterStub(
+		matchSecretCmd("pass show api/token"),
+		run.StringResponse("from-cmd\n"),
+	)
+
+	loader := NewLoader(&SecretConfig{}, path, WithSecretClient(client))
+	config, err := loader.Load()
+	assert.NoError(t, err)
+
+	// Not resolved until used.
+	assert.Equal(t, "cmd:pass show api/token", config.APIToken.Ref())
+	value, err := config.APIToken.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "from-cmd", value)
+
+	// Defaults are references too.
+	value, err = config.Password.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "from-env", value)
+
+	// Redacted when listing values.
+	for _, v := range loader.Values() {
+		assert.NotContains(t, fmt.Sprint(v.Value), "pass show")
+	}
+
+	// References (not redacted values) are written when saving.
+	assert.NoError(t, loader.Set("password", "file:~/.password"))
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "api_token: cmd:pass show api/token\npassword: file:~/.password\n", string(data))
+	assert.NoError(t, loader.Save())
+	data, err = os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "api_token: cmd:pass show api/token\npassword: file:~/.password\n", string(data))
+}
+
+func TestLoader_Secrets_Validate(t *testing.T) {
+	t.Setenv("SECRET_API_TOKEN", "")
+	loader := NewLoader(&SecretConfig{}, "")
+	_, err := loader.Load()
+	assert.EqualError(t, err, "APIToken is a required field")
+
+	t.Setenv("SECRET_API_TOKEN", "hunter2")
+	config, err := loader.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", config.APIToken.Ref())
+}
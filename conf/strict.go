@@ -0,0 +1,284 @@
+package conf
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// UnknownKey is a config file key or env var that does not map
+// to any field in the config struct.
+type UnknownKey struct {
+	// Key is the dot separated config file key, or the env var name.
+	Key string
+	// Path is the config file path (empty for env vars).
+	Path string
+	// Line and Column are the position of the key in the config file
+	// (zero if unknown, i.e. for TOML files).
+	Line   int
+	Column int
+	// EnvVar is true if Key is an env var name.
+	EnvVar bool
+	// Suggestion is the most similar known key or env var (if any).
+	Suggestion string
+}
+
+// String returns a message describing the unknown key.
+func (k UnknownKey) String() string {
+	msg := ""
+	switch {
+	case k.EnvVar:
+		msg = fmt.Sprintf("unknown env var %s", k.Key)
+	case k.Line > 0:
+		msg = fmt.Sprintf("%s:%d:%d: unknown key %s", k.Path, k.Line, k.Column, k.Key)
+	default:
+		msg = fmt.Sprintf("%s: unknown key %s", k.Path, k.Key)
+	}
+	if k.Suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean %s?)", k.Suggestion)
+	}
+	return msg
+}
+
+// UnknownKeysError is returned in strict mode when config files
+// or env vars contain unknown keys.
+type UnknownKeysError struct {
+	Keys []UnknownKey
+}
+
+func (e *UnknownKeysError) Error() string {
+	lines := []string{}
+	for _, k := range e.Keys {
+		lines = append(lines, k.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WithStrict causes loading to fail with an [*UnknownKeysError] when
+// a config file contains keys that do not map to any field in the
+// config struct (i.e. a misspelled "baseurl" rather than "base_url").
+// The line and column of each key are reported for YAML, JSON, and JSONC
+// files, but not for TOML (or custom format) files.
+func WithStrict() LoaderOpt {
+	return func(opts *loaderOptions) {
+		opts.strict = true
+	}
+}
+
+// WithStrictEnvPrefix also checks for env vars starting with prefix
+// (i.e. "MYAPP_") that do not map to any field.
+// Used with [WithStrict] or [WithUnknownKeyHandler].
+// Defaults to the loader env prefix (see [WithEnvPrefix]).
+func WithStrictEnvPrefix(prefix string) LoaderOpt {
+	return func(opts *loaderOptions) {
+		opts.strictEnv = prefix
+	}
+}
+
+// WithUnknownKeyHandler sets a func to be called for each unknown key
+// found when loading. Without [WithStrict], unknown keys are only
+// reported to fn (i.e. as warnings) and loading succeeds.
+func WithUnknownKeyHandler(fn func(k UnknownKey)) LoaderOpt {
+	return func(opts *loaderOptions) {
+		opts.onUnknownKey = fn
+	}
+}
+
+// strictEnabled returns true if unknown keys should be checked for.
+func (l *Loader[C]) strictEnabled() bool {
+	return l.opts.strict || l.opts.onUnknownKey != nil
+}
+
+// reportUnknownKeys passes keys to the unknown key handler and,
+// in strict mode, returns them as an error.
+func (l *Loader[C]) reportUnknownKeys(keys []UnknownKey) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if l.opts.onUnknownKey != nil {
+		for _, k := range keys {
+			l.opts.onUnknownKey(k)
+		}
+	}
+	if l.opts.strict {
+		return &UnknownKeysError{Keys: keys}
+	}
+	return nil
+}
+
+// unknownFileKeys returns the unknown keys in the config file at path,
+// given its source data and the data converted to YAML.
+func (l *Loader[C]) unknownFileKeys(path string, source []byte, data []byte) ([]UnknownKey, error) {
+	format, err := l.format(path)
+	if err != nil {
+		return nil, err
+	}
+	// Positions are only known if the source can be parsed as YAML.
+	root, positions := sourceNode(format, source), true
+	if root == nil {
+		root, positions = mappingNode(data), false
+	}
+	if root == nil {
+		// Syntax errors are left for the config unmarshal to report.
+		return nil, nil
+	}
+	// Check the migrated keys (migrations move nodes, so positions are kept).
+	if err := l.migrateNode(path, root, false); err != nil {
+		return nil, err
+	}
+
+	reserved := map[string]bool{}
+	if len(l.opts.migrations) > 0 {
+		reserved[VersionKey] = true
+	}
+	if l.opts.profiles {
+		reserved[ProfilesKey] = true
+		reserved[CurrentProfileKey] = true
+	}
+
+	t := reflect.TypeOf(l.newConfig())
+	known := knownKeys(walkDocFields(t, "", envScope{}, nil))
+	keys := []UnknownKey{}
+	check := func(node *yaml.Node, prefix string) {
+		walkNode(node, t, "", func(key string, keyNode *yaml.Node, sf *reflect.StructField) {
+			if sf != nil || (prefix == "" && reserved[key]) {
+				return
+			}
+			k := UnknownKey{
+				Key:        prefix + key,
+				Path:       path,
+				Suggestion: suggest(key, known),
+			}
+			if positions {
+				k.Line, k.Column = keyNode.Line, keyNode.Column
+			}
+			keys = append(keys, k)
+		})
+	}
+	check(root, "")
+	if profiles := findNode(root, []string{ProfilesKey}); l.opts.profiles && profiles != nil {
+		for i := 0; i+1 < len(profiles.Content); i += 2 {
+			name := profiles.Content[i].Value
+			check(profiles.Content[i+1], ProfilesKey+"."+name+".")
+		}
+	}
+	return keys, nil
+}
+
+// sourceNode returns the root mapping node of the source data
+// of a config file, or nil if it can't be parsed as YAML.
+// JSON is (for the most part) valid YAML, so positions are
+// available for JSON and JSONC (once comments are blanked out).
+func sourceNode(format *Format, source []byte) *yaml.Node {
+	switch format.Name {
+	case FormatYAML, FormatJSON:
+		return mappingNode(source)
+	case FormatJSONC:
+		return mappingNode(stripJSONC(source))
+	default:
+		return nil
+	}
+}
+
+// mappingNode returns the root mapping node of the YAML data,
+// or nil if it's invalid or not a mapping.
+func mappingNode(data []byte) *yaml.Node {
+	doc := &yaml.Node{}
+	if yaml.Unmarshal(data, doc) != nil || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	return doc.Content[0]
+}
+
+// unknownEnvVars returns the env vars with the strict env prefix
+// (or the loader env prefix) that do not map to any field.
+func (l *Loader[C]) unknownEnvVars() []UnknownKey {
+	prefix := l.opts.strictEnv
+	if prefix == "" {
+		prefix = l.opts.envPrefix
+	}
+	if prefix == "" {
+		return nil
+	}
+	known := map[string]bool{}
+	names := []string{}
+	for _, f := range l.envFields(reflect.ValueOf(l.newConfig())) {
+		if f.EnvVar != "" {
+			known[f.EnvVar] = true
+			names = append(names, f.EnvVar)
+		}
+	}
+	if l.opts.profileEnv != "" {
+		known[l.opts.profileEnv] = true
+	}
+
+	keys := []UnknownKey{}
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, prefix) || known[name] {
+			continue
+		}
+		keys = append(keys, UnknownKey{
+			Key:        name,
+			EnvVar:     true,
+			Suggestion: suggest(name, names),
+		})
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Key < keys[j].Key
+	})
+	return keys
+}
+
+// knownKeys returns the dot separated keys of each of the fields
+// (including nested structs).
+func knownKeys(fields []*docField) []string {
+	keys := []string{}
+	for _, f := range fields {
+		keys = append(keys, f.Key)
+		keys = append(keys, knownKeys(f.Children)...)
+	}
+	return keys
+}
+
+// suggest returns the candidate most similar to s, or an empty string
+// if none are similar enough (within roughly a third of the length).
+func suggest(s string, candidates []string) string {
+	best, bestDistance := "", -1
+	for _, c := range candidates {
+		d := levenshtein(strings.ToLower(s), strings.ToLower(c))
+		if bestDistance == -1 || d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+	if bestDistance < 0 || bestDistance > max(1, len(s)/3) {
+		return ""
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,152 @@
+package conf
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type StrictConfig struct {
+	BaseURL  string `yaml:"base_url" env:"STRICT_BASE_URL"`
+	Timeout  int    `yaml:"timeout" env:"STRICT_TIMEOUT"`
+	Database struct {
+		URL  string `yaml:"url"`
+		Pool int    `yaml:"pool"`
+	} `yaml:"database"`
+	Labels map[string]string `yaml:"labels"`
+}
+
+func TestLoader_Strict(t *testing.T) {
+	path := writeFixture(t, "config.yaml", `baseurl: https://example.com
+timeout: 10
+database:
+  url: postgres://localhost/db
+  pol: 5
+labels:
+  anything: goes
+zzz: true
+`)
+	loader := NewLoader(&StrictConfig{}, path, WithStrict())
+	_, err := loader.Load()
+
+	var unknownErr *UnknownKeysError
+	assert.True(t, errors.As(err, &unknownErr))
+	assert.Equal(t, []UnknownKey{
+		{Key: "baseurl", Path: path, Line: 1, Column: 1, Suggestion: "base_url"},
+		{Key: "database.pol", Path: path, Line: 5, Column: 3, Suggestion: "database.pool"},
+		{Key: "zzz", Path: path, Line: 8, Column: 1},
+	}, unknownErr.Keys)
+	assert.EqualError(t, err, path+":1:1: unknown key baseurl (did you mean base_url?)\n"+
+		path+":5:3: unknown key database.pol (did you mean database.pool?)\n"+
+		path+":8:1: unknown key zzz")
+}
+
+func TestLoader_Strict_Warnings(t *testing.T) {
+	path := writeFixture(t, "config.yaml", "baseurl: https://example.com\ntimeout: 10\n")
+	t.Setenv("STRICT_BASE_URL", "https://env.example.com")
+	t.Setenv("STRICT_TIMEOTU", "20")
+
+	warnings := []string{}
+	loader := NewLoader(&StrictConfig{}, path,
+		WithStrictEnvPrefix("STRICT_"),
+		WithUnknownKeyHandler(func(k UnknownKey) {
+			warnings = append(warnings, k.String())
+		}),
+	)
+	config, err := loader.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, 10, config.Timeout)
+	assert.Equal(t, []string{
+		path + ":1:1: unknown key baseurl (did you mean base_url?)",
+		"unknown env var STRICT_TIMEOTU (did you mean STRICT_TIMEOUT?)",
+	}, warnings)
+
+	// Only reported when loading (not when writing).
+	warnings = []string{}
+	assert.NoError(t, loader.Set("timeout", "30"))
+	assert.Empty(t, warnings)
+}
+
+func TestLoader_Strict_NonYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		position string
+	}{
+		{
+			name:     "config.json",
+			content:  `{"timeout": 10, "base_ulr": "x"}`,
+			position: ":1:17",
+		},
+		{
+			name:     "config.jsonc",
+			content:  "{\n\t// comment\n\t\"timeout\": 10, /* x */ \"base_ulr\": \"x\",\n}",
+			position: ":3:25",
+		},
+		{
+			name:     "config.toml",
+			content:  "timeout = 10\nbase_ulr = \"x\"\n",
+			position: "", // not available for TOML
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFixture(t, tt.name, tt.content)
+			loader := NewLoader(&StrictConfig{}, path, WithStrict())
+			_, err := loader.Load()
+			assert.EqualError(t, err, path+tt.position+": unknown key base_ulr (did you mean base_url?)")
+		})
+	}
+}
+
+func TestLoader_Strict_ReservedKeys(t *testing.T) {
+	path := writeFixture(t, "config.yaml", `version: 1
+current_profile: dev
+timeout: 10
+profiles:
+  dev:
+    base_urll: x
+`)
+	loader := NewLoader(&StrictConfig{}, path,
+		WithStrict(),
+		WithProfiles(""),
+		WithMigrations(RenameKey("url", "base_url")),
+	)
+	_, err := loader.Load()
+	assert.EqualError(t, err, path+":6:5: unknown key profiles.dev.base_urll (did you mean base_url?)")
+
+	// Reserved keys are unknown when the features are not enabled.
+	loader = NewLoader(&StrictConfig{}, path, WithStrict())
+	_, err = loader.Load()
+	assert.EqualError(t, err, path+":1:1: unknown key version\n"+
+		path+":2:1: unknown key current_profile\n"+
+		path+":4:1: unknown key profiles")
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"base_url", "timeout", "database.url"}
+	assert.Equal(t, "base_url", suggest("baseurl", candidates))
+	assert.Equal(t, "base_url", suggest("BASE_URL", candidates))
+	assert.Equal(t, "timeout", suggest("timout", candidates))
+	assert.Equal(t, "database.url", suggest("database.uri", candidates))
+	assert.Equal(t, "", suggest("xyz", candidates))
+	assert.Equal(t, "", suggest("xyz", nil))
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"baseurl", "base_url", 1},
+		{"héllo", "hello", 1},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, levenshtein(tt.a, tt.b), "%s -> %s", tt.a, tt.b)
+	}
+}
@@ -0,0 +1,201 @@
+package conf
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/twelvelabs/termite/validate"
+)
+
+const (
+	// DefaultDebounce is how long Watch waits for writes to settle before reloading.
+	DefaultDebounce = 100 * time.Millisecond
+	// DefaultPollInterval is how often Watch checks for changes when polling.
+	DefaultPollInterval = time.Second
+)
+
+// WatchOpt allows setting optional watch params.
+type WatchOpt func(opts *watchOptions)
+
+type watchOptions struct {
+	debounce     time.Duration
+	pollInterval time.Duration
+	poll         bool
+	onError      func(err error)
+}
+
+// WithDebounce sets how long to wait for writes to settle before reloading.
+// Defaults to [DefaultDebounce].
+func WithDebounce(d time.Duration) WatchOpt {
+	return func(opts *watchOptions) {
+		opts.debounce = d
+	}
+}
+
+// WithPolling forces polling for changes every interval,
+// rather than using filesystem notifications.
+func WithPolling(interval time.Duration) WatchOpt {
+	return func(opts *watchOptions) {
+		opts.poll = true
+		opts.pollInterval = interval
+	}
+}
+
+// WithErrorHandler sets a func to be called when a reload fails
+// (i.e. because the changed config is invalid).
+func WithErrorHandler(fn func(err error)) WatchOpt {
+	return func(opts *watchOptions) {
+		opts.onError = fn
+	}
+}
+
+// Subscribe registers fn to be called with the old and new config
+// each time the config is reloaded by [Loader.Watch].
+// Returns a func that removes the subscription.
+func (l *Loader[C]) Subscribe(fn func(old C, new C)) func() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.subscribers == nil {
+		l.subscribers = map[int]func(old C, new C){}
+	}
+	id := l.nextSubID
+	l.nextSubID++
+	l.subscribers[id] = fn
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.subscribers, id)
+	}
+}
+
+// Watch starts watching the config files for changes until ctx is done.
+//
+// Changes are debounced, then the config is reloaded and validated.
+// Only valid configs are swapped in; errors are passed to the handler
+// set via [WithErrorHandler]. Each reload creates a new config struct
+// (rather than modifying the current one), so use [Loader.Current]
+// to access the latest config. Subscribers are notified after each reload.
+//
+// On Linux, changes are detected using inotify. Elsewhere (or if a config
+// dir does not exist), the files are polled every [DefaultPollInterval].
+// The config files to watch are determined when Watch is called.
+func (l *Loader[C]) Watch(ctx context.Context, opts ...WatchOpt) error {
+	options := watchOptions{
+		debounce:     DefaultDebounce,
+		pollInterval: DefaultPollInterval,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	paths := []string{}
+	for _, layer := range l.Layers() {
+		paths = append(paths, layer.Path)
+	}
+	if len(paths) == 0 {
+		return errors.New("no config files to watch")
+	}
+
+	changes := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
+	if options.poll || watchFiles(ctx, paths, notify) != nil {
+		go pollFiles(ctx, paths, options.pollInterval, notify)
+	}
+	go l.reloadOnChange(ctx, changes, options)
+	return nil
+}
+
+// reloadOnChange reloads the config once changes have settled.
+func (l *Loader[C]) reloadOnChange(ctx context.Context, changes <-chan struct{}, options watchOptions) {
+	timer := time.NewTimer(options.debounce)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-changes:
+			timer.Reset(options.debounce)
+		case <-timer.C:
+			if err := l.reloadAndSwap(); err != nil && options.onError != nil {
+				options.onError(err)
+			}
+		}
+	}
+}
+
+// reloadAndSwap loads and validates a new config struct and,
+// if valid, makes it the current config and notifies subscribers.
+func (l *Loader[C]) reloadAndSwap() error {
+	l.mu.RLock()
+	config, origins, err := l.decode(nil)
+	l.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if err := validate.Struct(config); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	old := l.Config
+	l.set(config, origins)
+	l.loaded = true
+	subscribers := make([]func(old C, new C), 0, len(l.subscribers))
+	for _, fn := range l.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	l.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(old, config)
+	}
+	return nil
+}
+
+// fileStat is the state of a watched file used to detect changes.
+type fileStat struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+func statFile(path string) fileStat {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStat{}
+	}
+	return fileStat{exists: true, modTime: info.ModTime(), size: info.Size()}
+}
+
+// pollFiles calls notify whenever one of paths is created, removed, or modified.
+func pollFiles(ctx context.Context, paths []string, interval time.Duration, notify func()) {
+	stats := map[string]fileStat{}
+	for _, p := range paths {
+		stats[p] = statFile(p)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, p := range paths {
+				stat := statFile(p)
+				if !reflect.DeepEqual(stat, stats[p]) {
+					stats[p] = stat
+					notify()
+				}
+			}
+		}
+	}
+}
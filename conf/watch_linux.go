@@ -0,0 +1,64 @@
+package conf
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const inotifyMask = unix.IN_CLOSE_WRITE | unix.IN_CREATE | unix.IN_DELETE |
+	unix.IN_MODIFY | unix.IN_MOVED_FROM | unix.IN_MOVED_TO
+
+// watchFiles uses inotify to call notify whenever one of paths changes.
+// The parent dirs are watched (rather than the files themselves) so that
+// files which are created, or replaced via rename, are detected.
+func watchFiles(ctx context.Context, paths []string, notify func()) error {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return err
+	}
+	dirs := map[int]string{}
+	names := map[string]bool{}
+	for _, p := range paths {
+		p, _ = filepath.Abs(p)
+		names[p] = true
+		wd, err := unix.InotifyAddWatch(fd, filepath.Dir(p), inotifyMask)
+		if err != nil {
+			_ = unix.Close(fd)
+			return err
+		}
+		dirs[wd] = filepath.Dir(p)
+	}
+
+	// Non-blocking, so reads use the runtime poller and are
+	// interrupted when the file is closed.
+	f := os.NewFile(uintptr(fd), "inotify")
+	go func() {
+		<-ctx.Done()
+		_ = f.Close()
+	}()
+	go func() {
+		buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+		for {
+			n, err := f.Read(buf)
+			if err != nil {
+				return
+			}
+			for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+				event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+				start := offset + unix.SizeofInotifyEvent
+				end := start + int(event.Len)
+				name := strings.TrimRight(string(buf[start:end]), "\x00")
+				if names[filepath.Join(dirs[int(event.Wd)], name)] {
+					notify()
+				}
+				offset = end
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package conf
+
+import (
+	"context"
+	"errors"
+)
+
+// watchFiles is only supported on Linux; callers fall back to polling.
+func watchFiles(ctx context.Context, paths []string, notify func()) error {
+	return errors.New("file notifications not supported")
+}
@@ -0,0 +1,179 @@
+package conf
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type watchChange struct {
+	old *MyConfig
+	new *MyConfig
+}
+
+func watchLoader(t *testing.T, opts ...WatchOpt) (*Loader[*MyConfig], string, chan watchChange, chan error) {
+	t.Helper()
+	path := writeFixture(t, "config.yaml", "name: aaa\n")
+
+	loader := NewLoader(&MyConfig{}, path)
+	_, err := loader.Load()
+	assert.NoError(t, err)
+
+	changes := make(chan watchChange, 10)
+	errs := make(chan error, 10)
+	loader.Subscribe(func(old, new *MyConfig) {
+		changes <- watchChange{old: old, new: new}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	opts = append([]WatchOpt{
+		WithDebounce(10 * time.Millisecond),
+		WithErrorHandler(func(err error) { errs <- err }),
+	}, opts...)
+	assert.NoError(t, loader.Watch(ctx, opts...))
+	return loader, path, changes, errs
+}
+
+func TestLoader_Watch(t *testing.T) {
+	tests := []struct {
+		desc string
+		opts []WatchOpt
+	}{
+		{
+			desc: "notifications",
+		},
+		{
+			desc: "polling",
+			opts: []WatchOpt{WithPolling(10 * time.Millisecond)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			loader, path, changes, errs := watchLoader(t, tt.opts...)
+
+			// Polling compares mod times, so make sure they differ.
+			time.Sleep(20 * time.Millisecond)
+			assert.NoError(t, os.WriteFile(path, []byte("name: bbb\n"), 0600))
+			select {
+			case change := <-changes:
+				assert.Equal(t, "aaa", change.old.Name)
+				assert.Equal(t, "bbb", change.new.Name)
+				assert.Equal(t, "bbb", loader.Current().Name)
+			case err := <-errs:
+				t.Fatalf("unexpected error: %v", err)
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for change")
+			}
+
+			// Invalid changes are reported and not swapped in.
+			time.Sleep(20 * time.Millisecond)
+			assert.NoError(t, os.WriteFile(path, []byte("name: ccc\ncount: 200\n"), 0600))
+			select {
+			case change := <-changes:
+				t.Fatalf("unexpected change: %v", change.new)
+			case err := <-errs:
+				assert.ErrorContains(t, err, "Count")
+				assert.Equal(t, "bbb", loader.Current().Name)
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for error")
+			}
+		})
+	}
+}
+
+func TestLoader_Watch_Debounce(t *testing.T) {
+	loader, path, changes, _ := watchLoader(t, WithDebounce(200*time.Millisecond))
+
+	for _, name := range []string{"bbb", "ccc", "ddd"} {
+		assert.NoError(t, os.WriteFile(path, []byte("name: "+name+"\n"), 0600))
+	}
+	select {
+	case change := <-changes:
+		assert.Equal(t, "aaa", change.old.Name)
+		assert.Equal(t, "ddd", change.new.Name)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for change")
+	}
+	select {
+	case change := <-changes:
+		t.Fatalf("unexpected change: %v", change.new)
+	case <-time.After(300 * time.Millisecond):
+	}
+	assert.Equal(t, "ddd", loader.Current().Name)
+}
+
+func TestLoader_Watch_WhenNoFiles(t *testing.T) {
+	loader := NewLoader(&MyConfig{}, "")
+	err := loader.Watch(context.Background())
+	assert.ErrorContains(t, err, "no config files to watch")
+}
+
+func TestLoader_Subscribe(t *testing.T) {
+	path := writeFixture(t, "config.yaml", "name: aaa\n")
+	loader := NewLoader(&MyConfig{}, path)
+
+	calls := 0
+	unsubscribe := loader.Subscribe(func(old, new *MyConfig) {
+		calls++
+	})
+	assert.NoError(t, loader.reloadAndSwap())
+	assert.Equal(t, 1, calls)
+
+	unsubscribe()
+	assert.NoError(t, loader.reloadAndSwap())
+	assert.Equal(t, 1, calls)
+}
+
+func TestLoader_Current_Concurrent(t *testing.T) {
+	path := writeFixture(t, "config.yaml", "name: aaa\n")
+	loader := NewLoader(&MyConfig{}, path)
+	_, err := loader.Load()
+	assert.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				assert.Equal(t, "aaa", loader.Current().Name)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				assert.NoError(t, loader.reloadAndSwap())
+				_, err := loader.Reload()
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLoader_Current_WhenSet(t *testing.T) {
+	path := writeFixture(t, "config.yaml", "name: aaa\n")
+	loader := NewLoader(&MyConfig{}, path)
+	config, err := loader.Load()
+	assert.NoError(t, err)
+
+	// Each change creates a new config struct (run w/ -race to verify).
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 20; j++ {
+			assert.Equal(t, "aaa", config.Name)
+		}
+	}()
+	assert.NoError(t, loader.Set("name", "bbb"))
+	wg.Wait()
+
+	assert.Equal(t, "aaa", config.Name)
+	assert.Equal(t, "bbb", loader.Current().Name)
+}
@@ -21,6 +21,8 @@ var (
 	filepathAbs   = filepath.Abs
 	osMkdirAll    = os.MkdirAll
 	osWriteFile   = os.WriteFile
+	osCreateTemp  = os.CreateTemp
+	osRename      = os.Rename
 )
 
 func NoPathExists(path string) bool {
@@ -86,3 +88,69 @@ func EnsureDirWritable(path string) error {
 
 	return nil
 }
+
+// WriteFileAtomic writes data to the named file with permissions perm.
+// The data is written to a temp file in the same dir, which is then
+// renamed over the original, so readers never see a partial write.
+// If name is a symlink, the file it points to is replaced
+// (rather than the link itself).
+func WriteFileAtomic(name string, data []byte, perm os.FileMode) error {
+	name, err := resolveSymlinks(name)
+	if err != nil {
+		return fmt.Errorf("write atomic: %w", err)
+	}
+	dir, base := filepath.Split(name)
+	if dir == "" {
+		dir = "."
+	}
+	f, err := osCreateTemp(dir, "."+base+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write atomic: %w", err)
+	}
+	tmp := f.Name()
+	defer func() {
+		// No-op once renamed.
+		_ = os.Remove(tmp)
+	}()
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write atomic: %w", err)
+	}
+	if err := f.Chmod(perm); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write atomic: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write atomic: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write atomic: %w", err)
+	}
+	if err := osRename(tmp, name); err != nil {
+		return fmt.Errorf("write atomic: %w", err)
+	}
+	return nil
+}
+
+// resolveSymlinks follows name while it is a symlink and returns
+// the final path. Unlike [filepath.EvalSymlinks], the final path
+// need not exist (i.e. a dangling link to a file yet to be written).
+func resolveSymlinks(name string) (string, error) {
+	for range 255 {
+		info, err := os.Lstat(name)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			return name, nil
+		}
+		target, err := os.Readlink(name)
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(name), target)
+		}
+		name = target
+	}
+	return "", &os.PathError{Op: "readlink", Path: name, Err: syscall.ELOOP}
+}
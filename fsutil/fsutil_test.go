@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"syscall"
 	"testing"
 
 	"github.com/prashantv/gostub"
@@ -147,3 +148,80 @@ func TestEnsureDirWritable_WhenWriteFileError(t *testing.T) {
 	err := EnsureDirWritable("foo")
 	assert.Error(t, err)
 }
+
+func TestWriteFileAtomic(t *testing.T) {
+	testutil.InTempDir(t, func(tmpDir string) {
+		path := filepath.Join(tmpDir, "config.yaml")
+		err := WriteFileAtomic(path, []byte("foo"), DefaultFileMode)
+		assert.NoError(t, err)
+
+		err = WriteFileAtomic(path, []byte("bar"), DefaultFileMode)
+		assert.NoError(t, err)
+
+		data, _ := os.ReadFile(path)
+		assert.Equal(t, "bar", string(data))
+		info, _ := os.Stat(path)
+		assert.Equal(t, os.FileMode(DefaultFileMode), info.Mode().Perm())
+
+		// Relative to the working dir
+		err = WriteFileAtomic("other.yaml", []byte("baz"), DefaultFileMode)
+		assert.NoError(t, err)
+		assert.FileExists(t, filepath.Join(tmpDir, "other.yaml"))
+
+		// No temp files left behind
+		entries, _ := os.ReadDir(tmpDir)
+		assert.Len(t, entries, 2)
+	})
+}
+
+func TestWriteFileAtomic_WhenSymlink(t *testing.T) {
+	testutil.InTempDir(t, func(tmpDir string) {
+		assert.NoError(t, os.Mkdir("dotfiles", DefaultDirMode))
+		assert.NoError(t, os.WriteFile("dotfiles/config.yaml", []byte("foo"), DefaultFileMode))
+		assert.NoError(t, os.Symlink("dotfiles/config.yaml", "config.yaml"))
+
+		err := WriteFileAtomic("config.yaml", []byte("bar"), DefaultFileMode)
+		assert.NoError(t, err)
+
+		// The link is kept and the target is updated.
+		target, err := os.Readlink("config.yaml")
+		assert.NoError(t, err)
+		assert.Equal(t, "dotfiles/config.yaml", target)
+		data, _ := os.ReadFile("dotfiles/config.yaml")
+		assert.Equal(t, "bar", string(data))
+
+		// Dangling links create the target.
+		assert.NoError(t, os.Symlink(filepath.Join(tmpDir, "dotfiles/new.yaml"), "new.yaml"))
+		err = WriteFileAtomic("new.yaml", []byte("baz"), DefaultFileMode)
+		assert.NoError(t, err)
+		data, _ = os.ReadFile("dotfiles/new.yaml")
+		assert.Equal(t, "baz", string(data))
+
+		// Symlink loops are an error.
+		assert.NoError(t, os.Symlink("loop.yaml", "loop.yaml"))
+		err = WriteFileAtomic("loop.yaml", []byte("baz"), DefaultFileMode)
+		assert.ErrorIs(t, err, syscall.ELOOP)
+	})
+}
+
+func TestWriteFileAtomic_WhenCreateTempError(t *testing.T) {
+	stubs := gostub.StubFunc(&osCreateTemp, nil, errors.New("boom"))
+	defer stubs.Reset()
+
+	err := WriteFileAtomic("foo", []byte(""), DefaultFileMode)
+	assert.ErrorContains(t, err, "boom")
+}
+
+func TestWriteFileAtomic_WhenRenameError(t *testing.T) {
+	stubs := gostub.StubFunc(&osRename, errors.New("boom"))
+	defer stubs.Reset()
+
+	testutil.InTempDir(t, func(tmpDir string) {
+		err := WriteFileAtomic("foo", []byte(""), DefaultFileMode)
+		assert.ErrorContains(t, err, "boom")
+
+		// Temp file should be cleaned up
+		entries, _ := os.ReadDir(tmpDir)
+		assert.Len(t, entries, 0)
+	})
+}
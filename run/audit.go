@@ -0,0 +1,257 @@
+package run
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/twelvelabs/termite/fsutil"
+)
+
+const (
+	// DefaultAuditMaxSize is the size (in bytes) at which the audit log is rotated.
+	DefaultAuditMaxSize int64 = 10 * 1024 * 1024
+	// DefaultAuditMaxBackups is the number of rotated audit logs to keep.
+	DefaultAuditMaxBackups = 3
+)
+
+// AuditEntry is a single command invocation recorded in an audit log.
+type AuditEntry struct {
+	Timestamp time.Time     `json:"timestamp"`
+	Args      []string      `json:"argv"`
+	Dir       string        `json:"cwd"`
+	ExitCode  int           `json:"exit_code"`
+	Duration  time.Duration `json:"duration"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// AuditEntries is a list of audit log entries.
+type AuditEntries []*AuditEntry
+
+// Filter returns the entries for which fn returns true.
+func (es AuditEntries) Filter(fn func(e *AuditEntry) bool) AuditEntries {
+	filtered := AuditEntries{}
+	for _, e := range es {
+		if fn(e) {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// Since returns the entries recorded at or after t.
+func (es AuditEntries) Since(t time.Time) AuditEntries {
+	return es.Filter(func(e *AuditEntry) bool {
+		return !e.Timestamp.Before(t)
+	})
+}
+
+// Last returns (at most) the last n entries.
+func (es AuditEntries) Last(n int) AuditEntries {
+	if n < len(es) {
+		return es[len(es)-n:]
+	}
+	return es
+}
+
+// NewAuditExecutor returns a new AuditExecutor that wraps executor
+// and appends an entry to the log file at path for each command.
+// If executor is nil, [DefaultExecutor] is used.
+//
+// Audit logs are typically stored in the app's state dir:
+//
+//	path := filepath.Join(conf.StateDir("myapp"), "audit.log")
+//	client.Executor = run.NewAuditExecutor(path, client.Executor)
+func NewAuditExecutor(path string, executor Executor) *AuditExecutor {
+	if executor == nil {
+		executor = DefaultExecutor
+	}
+	return &AuditExecutor{
+		Executor:   executor,
+		Path:       path,
+		Filter:     MatchAny,
+		MaxSize:    DefaultAuditMaxSize,
+		MaxBackups: DefaultAuditMaxBackups,
+	}
+}
+
+// AuditExecutor is an implementation of Executor that records
+// every command it runs as a JSON line in an audit log.
+// Secrets registered on the command are redacted.
+//
+// The log is rotated once it exceeds MaxSize bytes,
+// with older logs renamed to "<path>.1", "<path>.2", etc.
+type AuditExecutor struct {
+	// Executor runs the commands.
+	Executor Executor
+	// Path is the location of the audit log.
+	Path string
+	// Filter determines which commands are logged (i.e. only mutating ones).
+	// Defaults to all commands.
+	Filter Matcher
+	// MaxSize is the size (in bytes) at which the log is rotated.
+	// Zero disables rotation.
+	MaxSize int64
+	// MaxBackups is the number of rotated logs to keep.
+	MaxBackups int
+
+	mu sync.Mutex
+}
+
+func (e *AuditExecutor) wrapped() Executor {
+	return e.Executor
+}
+
+func (e *AuditExecutor) ExitCode(cmd *Cmd) int {
+	return e.Executor.ExitCode(cmd)
+}
+
+func (e *AuditExecutor) Output(cmd *Cmd) ([]byte, error) {
+	start := time.Now()
+	buf, err := e.Executor.Output(cmd)
+	return buf, e.audit(cmd, start, err)
+}
+
+func (e *AuditExecutor) Run(cmd *Cmd) error {
+	start := time.Now()
+	err := e.Executor.Run(cmd)
+	return e.audit(cmd, start, err)
+}
+
+// audit logs cmd (if it matches the filter) and returns the command error.
+// Failure to write the log is only reported if the command succeeded.
+func (e *AuditExecutor) audit(cmd *Cmd, start time.Time, err error) error {
+	if e.Filter != nil && !e.Filter(cmd) {
+		return err
+	}
+	entry := &AuditEntry{
+		Timestamp: start.UTC(),
+		Args:      cmd.redactAll(cmd.Args),
+		Dir:       cmd.Dir,
+		ExitCode:  e.Executor.ExitCode(cmd),
+		Duration:  time.Since(start),
+	}
+	if entry.Dir == "" {
+		entry.Dir, _ = os.Getwd()
+	}
+	if err != nil {
+		entry.Error = cmd.Redact(err.Error())
+	}
+	if logErr := e.write(entry); logErr != nil && err == nil {
+		return fmt.Errorf("audit log: %w", logErr)
+	}
+	return err
+}
+
+func (e *AuditExecutor) write(entry *AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(e.Path), fsutil.DefaultDirMode); err != nil {
+		return err
+	}
+	if err := e.rotate(int64(len(data))); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(e.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, fsutil.DefaultFileMode)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// rotate renames the log if writing n more bytes would exceed MaxSize.
+func (e *AuditExecutor) rotate(n int64) error {
+	if e.MaxSize <= 0 {
+		return nil
+	}
+	info, err := os.Stat(e.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if info.Size() == 0 || info.Size()+n <= e.MaxSize {
+		return nil
+	}
+
+	if e.MaxBackups <= 0 {
+		return os.Remove(e.Path)
+	}
+	_ = os.Remove(auditBackupPath(e.Path, e.MaxBackups))
+	for i := e.MaxBackups - 1; i >= 1; i-- {
+		err := os.Rename(auditBackupPath(e.Path, i), auditBackupPath(e.Path, i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(e.Path, auditBackupPath(e.Path, 1))
+}
+
+// ReadAuditLog returns the entries in the audit log at path,
+// including any rotated logs, oldest first.
+// Returns an empty list if no log exists.
+func ReadAuditLog(path string) (AuditEntries, error) {
+	paths := []string{}
+	for i := 1; ; i++ {
+		backup := auditBackupPath(path, i)
+		if fsutil.NoPathExists(backup) {
+			break
+		}
+		paths = append([]string{backup}, paths...)
+	}
+	paths = append(paths, path)
+
+	entries := AuditEntries{}
+	for _, p := range paths {
+		if err := readAuditFile(p, &entries); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+func readAuditFile(path string, entries *AuditEntries) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		entry := &AuditEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		*entries = append(*entries, entry)
+	}
+	return scanner.Err()
+}
+
+func auditBackupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
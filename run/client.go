@@ -3,6 +3,7 @@ package run
 import (
 	"context"
 	"os/exec"
+	"sync"
 )
 
 // NewClient returns a new Client.
@@ -15,6 +16,13 @@ func NewClient() *Client {
 // Client is an abstraction around [os/exec] to support stubbing.
 type Client struct {
 	Executor Executor
+
+	// ResultHook, if set, is called with the result of each command
+	// run via [Cmd.Run] or [Cmd.Output]. Useful for exporting metrics.
+	ResultHook func(result *Result)
+
+	mu    sync.Mutex
+	tools map[string]*toolInfo
 }
 
 // Command returns the Cmd struct to execute the named program with
@@ -25,9 +33,18 @@ func (c *Client) Command(name string, arg ...string) *Cmd {
 }
 
 // CommandContext is like Command but includes a context.
+//
+// Unlike [os/exec.CommandContext], the process is not immediately killed
+// when the context is done. Instead it is sent a termination signal and given
+// a grace period to exit (see [Cmd.WithTerminateSignal] and [Cmd.WithGracePeriod]).
+//
+// The context is also bound to the embedded [os/exec.Cmd], so executors that
+// run it directly still stop the process when the context is done (though
+// they are only sent the termination signal, without the grace period).
 func (c *Client) CommandContext(ctx context.Context, name string, arg ...string) *Cmd {
-	cmd := exec.CommandContext(ctx, name, arg...)
-	return &Cmd{Cmd: cmd, client: c, exitCode: -1}
+	cmd := &Cmd{Cmd: exec.CommandContext(ctx, name, arg...), client: c, exitCode: -1, ctx: ctx}
+	cmd.Cmd.Cancel = cmd.interrupt
+	return cmd
 }
 
 // IsStubbed returns true if the executor is configured for stubbing.
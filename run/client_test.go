@@ -29,6 +29,11 @@ func TestClient_CommandContext(t *testing.T) {
 	cmd := client.CommandContext(ctx, "/bin/echo", "foo", "bar")
 	assert.Equal(t, "/bin/echo", cmd.Path)
 	assert.Equal(t, []string{"/bin/echo", "foo", "bar"}, cmd.Args)
+	assert.Equal(t, ctx, cmd.Context())
+
+	assert.PanicsWithValue(t, "nil Context", func() {
+		client.CommandContext(nil, "/bin/echo") //nolint:staticcheck
+	})
 }
 
 func TestClient_RegisterStub(t *testing.T) {
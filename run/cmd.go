@@ -2,9 +2,28 @@ package run
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	shellSafeRegexp = regexp.MustCompile(`^[\w@%+=:,./-]+$`)
+	shellNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+const (
+	// DefaultGracePeriod is how long a timed out or canceled command is given
+	// to exit after receiving the termination signal before it is killed.
+	DefaultGracePeriod = 5 * time.Second
 )
 
 // Cmd is a wrapper around [os/exec.Cmd] that supports stubbing.
@@ -14,6 +33,24 @@ type Cmd struct {
 
 	client   *Client
 	exitCode int // used when stubbing
+
+	ctx         context.Context
+	runCtx      context.Context
+	timeout     time.Duration
+	termSignal  os.Signal
+	gracePeriod time.Duration
+	sup         *supervisor
+
+	pty *ptyOptions
+
+	envOps   []envOp
+	hermetic bool
+	secrets  []string
+
+	result *Result
+	// stubDuration is the time.Duration set by [WithDuration].
+	// Atomic because responders may run in their own goroutine.
+	stubDuration atomic.Int64
 }
 
 // ExitCode returns the exit code for the command.
@@ -23,12 +60,65 @@ func (c *Cmd) ExitCode() int {
 
 // Output runs the command and returns its standard output.
 func (c *Cmd) Output() ([]byte, error) {
-	return c.client.Executor.Output(c)
+	if err := c.prepare(); err != nil {
+		return nil, err
+	}
+	stderr, restore := c.tailStderr(true)
+	start := time.Now()
+	buf, err := c.client.Executor.Output(c)
+	restore()
+	err = c.exitError(err, start, stderr)
+	c.finish(start, err)
+	return buf, err
 }
 
 // Run starts the specified command and waits for it to complete.
 func (c *Cmd) Run() error {
-	return c.client.Executor.Run(c)
+	if err := c.prepare(); err != nil {
+		return err
+	}
+	stderr, restore := c.tailStderr(false)
+	start := time.Now()
+	err := c.client.Executor.Run(c)
+	restore()
+	err = c.exitError(err, start, stderr)
+	c.finish(start, err)
+	return err
+}
+
+// WithTimeout limits how long the command may run.
+// When the timeout elapses the command is terminated
+// and a [TimeoutError] is returned.
+func (c *Cmd) WithTimeout(d time.Duration) *Cmd {
+	c.timeout = d
+	return c
+}
+
+// WithTerminateSignal sets the signal sent to the command's process group
+// when it times out or is canceled. Defaults to SIGTERM.
+func (c *Cmd) WithTerminateSignal(sig os.Signal) *Cmd {
+	c.termSignal = sig
+	return c
+}
+
+// WithGracePeriod sets how long the command is given to exit after being
+// sent the terminate signal before the process group is killed.
+// Defaults to [DefaultGracePeriod].
+func (c *Cmd) WithGracePeriod(d time.Duration) *Cmd {
+	c.gracePeriod = d
+	return c
+}
+
+// Context returns the context for the command.
+// While the command is running this includes any deadline set via [Cmd.WithTimeout].
+func (c *Cmd) Context() context.Context {
+	if c.runCtx != nil {
+		return c.runCtx
+	}
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
 }
 
 // PeekStdin reads Stdin without moving the read offset to EOF.
@@ -46,14 +136,163 @@ func (c *Cmd) PeekStdin() ([]byte, error) {
 }
 
 // DebugString the command string plus a truncated preview of stdin.
+// Secrets are redacted.
 func (c *Cmd) DebugString() string {
-	stdin, _ := c.PeekStdin()
-	if len(stdin) > 20 {
-		stdin = append(stdin[:20], []byte("…")...)
-	}
+	data, _ := c.PeekStdin()
+	stdin := c.Redact(string(data))
+	stdin = truncate(stdin, 20)
 	suffix := ""
 	if len(stdin) > 0 {
-		suffix = fmt.Sprintf(" [Stdin: \"%s\"]", string(stdin))
+		suffix = fmt.Sprintf(" [Stdin: \"%s\"]", stdin)
+	}
+	return c.Redact(c.String()) + suffix
+}
+
+// ShellString returns the command as a copy-pasteable, shell quoted string.
+// Any changes to the working dir, environment, or stdin are included,
+// and secrets are redacted.
+//
+// For example:
+//
+//	cd /src/app && env -u DEBUG GOOS=linux go build -o 'my app' .
+//
+// Commands with a hermetic environment (see [Cmd.WithHermeticEnv])
+// are run via `env -i` with each of their variables.
+func (c *Cmd) ShellString() string {
+	parts := []string{}
+	if c.Dir != "" {
+		parts = append(parts, "cd", shellQuote(c.Dir), "&&")
+	}
+	if _, ok := c.Stdin.(*os.File); !ok {
+		stdin, _ := c.PeekStdin()
+		if len(stdin) > 0 {
+			parts = append(parts, "printf", "%s", shellQuote(string(stdin)), "|")
+		}
+	}
+
+	set, unset := envDiff(c)
+	if c.hermetic {
+		// Start from an empty environment rather than unsetting
+		// every inherited variable.
+		set, unset = hermeticEnv(c), nil
+		parts = append(parts, "env", "-i")
+	} else if len(unset) > 0 || !shellAssignable(set) {
+		parts = append(parts, "env")
+		for _, k := range unset {
+			parts = append(parts, "-u", shellQuote(k))
+		}
+	}
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		parts = append(parts, shellAssignment(k, set[k]))
+	}
+
+	for _, arg := range c.Args {
+		parts = append(parts, shellQuote(arg))
+	}
+	return c.Redact(strings.Join(parts, " "))
+}
+
+// isCancelable returns true if the command has a context or timeout
+// and must be supervised while it runs.
+func (c *Cmd) isCancelable() bool {
+	return c.ctx != nil || c.timeout > 0
+}
+
+// startContext returns the context governing a single run of the command.
+func (c *Cmd) startContext() (context.Context, context.CancelFunc) {
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var cancel context.CancelFunc
+	if c.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	c.runCtx = ctx
+	return ctx, cancel
+}
+
+// interrupt is the Cancel func of the embedded [os/exec.Cmd]
+// (see [Client.CommandContext]).
+func (c *Cmd) interrupt() error {
+	if c.sup != nil {
+		return c.sup.cancel()
+	}
+	return c.Process.Signal(c.terminateSignal())
+}
+
+func (c *Cmd) terminateSignal() os.Signal {
+	if c.termSignal != nil {
+		return c.termSignal
+	}
+	return defaultTerminateSignal
+}
+
+func (c *Cmd) terminateGracePeriod() time.Duration {
+	if c.gracePeriod > 0 {
+		return c.gracePeriod
+	}
+	return DefaultGracePeriod
+}
+
+// timeoutError returns a TimeoutError for the command.
+func (c *Cmd) timeoutError(cause error, killed bool) *TimeoutError {
+	return &TimeoutError{
+		Args:    c.redactAll(c.Args),
+		Timeout: c.timeout,
+		Signal:  c.terminateSignal(),
+		Killed:  killed,
+		Err:     cause,
+	}
+}
+
+// truncate returns the first n bytes of s followed by an ellipsis
+// if s is longer (or fewer, so that a multi-byte character isn't split).
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "…"
+}
+
+// shellAssignable returns true if each of the env var names
+// can be assigned by the shell (i.e. `KEY=value cmd`).
+func shellAssignable(env map[string]string) bool {
+	for k := range env {
+		if !shellNameRegexp.MatchString(k) {
+			return false
+		}
+	}
+	return true
+}
+
+// shellAssignment returns `key=value`, shell quoted.
+// Names that aren't valid shell identifiers are quoted along with the value
+// (they are only valid as arguments to `env`).
+func shellAssignment(key string, value string) string {
+	if shellNameRegexp.MatchString(key) {
+		return key + "=" + shellQuote(value)
+	}
+	return shellQuote(key + "=" + value)
+}
+
+// shellQuote quotes s for use in a POSIX shell (if needed).
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if shellSafeRegexp.MatchString(s) {
+		return s
 	}
-	return c.String() + suffix
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
 }
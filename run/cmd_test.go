@@ -2,8 +2,12 @@ package run
 
 import (
 	"bytes"
+	"context"
 	"io"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -57,6 +61,9 @@ func TestCmd_DebugString(t *testing.T) {
 
 	cmd.Stdin = bytes.NewBufferString("Lorem ipsum dolor sit amet") // cspell:disable-line
 	assert.Equal(t, `/bin/cat [Stdin: "Lorem ipsum dolor si…"]`, cmd.DebugString())
+
+	cmd.Stdin = bytes.NewBufferString("Lorem ipsum dolor s€ amet") // cspell:disable-line
+	assert.Equal(t, `/bin/cat [Stdin: "Lorem ipsum dolor s…"]`, cmd.DebugString())
 }
 
 func TestCmd_PeekStdin(t *testing.T) {
@@ -85,3 +92,68 @@ func TestCmd_PeekStdin(t *testing.T) {
 	assert.Equal(t, "", string(data))
 	assert.NoError(t, err)
 }
+
+func TestCmd_Context(t *testing.T) {
+	client := NewClient()
+
+	cmd := client.Command("/bin/echo")
+	assert.Equal(t, context.Background(), cmd.Context())
+
+	ctx := context.WithValue(context.Background(), struct{}{}, "value")
+	cmd = client.CommandContext(ctx, "/bin/echo")
+	assert.Equal(t, ctx, cmd.Context())
+}
+
+func TestCmd_TerminationOptions(t *testing.T) {
+	client := NewClient()
+
+	cmd := client.Command("/bin/echo")
+	assert.Equal(t, defaultTerminateSignal, cmd.terminateSignal())
+	assert.Equal(t, DefaultGracePeriod, cmd.terminateGracePeriod())
+	assert.Equal(t, false, cmd.isCancelable())
+
+	cmd = cmd.
+		WithTimeout(time.Second).
+		WithTerminateSignal(os.Interrupt).
+		WithGracePeriod(time.Millisecond)
+	assert.Equal(t, os.Interrupt, cmd.terminateSignal())
+	assert.Equal(t, time.Millisecond, cmd.terminateGracePeriod())
+	assert.Equal(t, true, cmd.isCancelable())
+}
+
+func TestCmd_ShellString(t *testing.T) {
+	client := NewClient()
+
+	cmd := client.Command("echo", "foo", "")
+	assert.Equal(t, `echo foo ''`, cmd.ShellString())
+
+	cmd = client.Command("git", "commit", "-m", "it's a $HOME")
+	cmd.Dir = "/src/my app"
+	assert.Equal(t, `cd '/src/my app' && git commit -m 'it'\''s a $HOME'`, cmd.ShellString())
+
+	cmd = client.Command("cat")
+	cmd.Stdin = bytes.NewBufferString("hello world")
+	assert.Equal(t, `printf %s 'hello world' | cat`, cmd.ShellString())
+
+	t.Setenv("SHELL_STRING_UNSET", "1")
+	cmd = client.Command("go", "build")
+	cmd.Env = []string{"GOOS=linux", "EMPTY="}
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "SHELL_STRING_UNSET=") {
+			cmd.Env = append(cmd.Env, kv)
+		}
+	}
+	assert.Equal(t, `env -u SHELL_STRING_UNSET EMPTY='' GOOS=linux go build`, cmd.ShellString())
+
+	cmd = client.Command("go", "build").WithEnv("GO.FLAGS", "-v x").WithEnv("GOOS", "linux")
+	assert.Equal(t, `env 'GO.FLAGS=-v x' GOOS=linux go build`, cmd.ShellString())
+
+	t.Setenv("SHELL_STRING_HOME", "/home/me")
+	cmd = client.Command("go", "build").
+		WithHermeticEnv("SHELL_STRING_HOME").
+		WithSecretEnv("TOKEN", "s3cret").
+		WithEnv("CI", "true")
+	cmd.Dir = "/src"
+	assert.Equal(t, `cd /src && env -i CI=true SHELL_STRING_HOME=/home/me TOKEN='[REDACTED]' go build`,
+		cmd.ShellString())
+}
@@ -1,5 +1,14 @@
 package run
 
+import (
+	"bytes"
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
 var (
 	// DefaultExecutor is the default implementation of Executor used by new Clients.
 	// Each of it's methods simply delegate to the underlying [os/exec.Cmd].
@@ -14,9 +23,143 @@ func (e *defaultExecutor) ExitCode(cmd *Cmd) int {
 }
 
 func (e *defaultExecutor) Output(cmd *Cmd) ([]byte, error) {
-	return cmd.Cmd.Output()
+	if err := cmd.prepare(); err != nil {
+		return nil, err
+	}
+	if cmd.pty != nil {
+		var out bytes.Buffer
+		err := e.runPTY(cmd, &out)
+		return out.Bytes(), err
+	}
+	if !cmd.isCancelable() {
+		return cmd.Cmd.Output()
+	}
+	return captureOutput(cmd, e.Run)
 }
 
 func (e *defaultExecutor) Run(cmd *Cmd) error {
-	return cmd.Cmd.Run()
+	if err := cmd.prepare(); err != nil {
+		return err
+	}
+	if cmd.pty != nil {
+		return e.runPTY(cmd, nil)
+	}
+	if !cmd.isCancelable() {
+		return cmd.Cmd.Run()
+	}
+
+	ctx, cancel := cmd.startContext()
+	defer cancel()
+
+	// Run the command in its own process group so that the termination
+	// signal reaches any children it spawns.
+	sup := supervise(ctx, cmd)
+	setProcessGroup(cmd.Cmd)
+	if err := cmd.Cmd.Start(); err != nil {
+		sup.stop()
+		return err
+	}
+	return sup.wait()
+}
+
+// supervisor terminates a command when its context is done.
+type supervisor struct {
+	cmd    *Cmd
+	ctx    context.Context
+	killed atomic.Bool
+
+	mu       sync.Mutex
+	canceled bool
+	exited   bool
+	timer    *time.Timer
+}
+
+// supervise terminates the command started next when ctx is done:
+// the command's process group is sent the terminate signal and, if the
+// process is still running after the grace period, the group is killed.
+// Once WaitDelay (defaulting to twice the grace period) has elapsed after
+// the command exits, [os/exec] also closes the command's pipes, so that
+// orphaned children holding stdout/stderr open don't block Wait.
+//
+// The underlying [os/exec.Cmd] is configured in place. Call wait
+// once it has started (or stop if it fails to start).
+func supervise(ctx context.Context, cmd *Cmd) *supervisor {
+	s := &supervisor{cmd: cmd, ctx: ctx}
+	if cmd.WaitDelay == 0 {
+		cmd.WaitDelay = 2 * cmd.terminateGracePeriod()
+	}
+	cmd.sup = s
+	return s
+}
+
+// stop stops supervising the command.
+func (s *supervisor) stop() {
+	s.cmd.sup = nil
+}
+
+// cancel signals the process group once ctx is done (it is also called
+// by [os/exec] when the context passed to [Client.CommandContext] is done).
+// The process group is only signaled while the leader has not been
+// reaped, since its ID may be reused afterwards.
+func (s *supervisor) cancel() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.exited {
+		return os.ErrProcessDone
+	}
+	if s.canceled {
+		return nil
+	}
+	s.canceled = true
+	_ = signalProcessGroup(s.cmd.Process, s.cmd.terminateSignal())
+	s.timer = time.AfterFunc(s.cmd.terminateGracePeriod(), func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !s.exited {
+			s.killed.Store(true)
+			_ = killProcessGroup(s.cmd.Process)
+		}
+	})
+	return nil
+}
+
+// wait waits for the started command to exit.
+// Returns a [TimeoutError] if it was terminated.
+func (s *supervisor) wait() error {
+	defer s.stop()
+	unwatch := context.AfterFunc(s.ctx, func() {
+		_ = s.cancel()
+	})
+	defer unwatch()
+
+	// Where supported, wait for the leader to exit without reaping it,
+	// so that any children that ignored the terminate signal can be killed
+	// while the process group ID is still reserved.
+	if awaitExit(s.cmd.Process) {
+		s.exit(true)
+	}
+	err := s.cmd.Cmd.Wait()
+	if !s.exit(false) {
+		return err
+	}
+	return s.cmd.timeoutError(s.ctx.Err(), s.killed.Load())
+}
+
+// exit records that the leader has exited, killing the rest of the
+// process group if it was canceled and killOrphans is true.
+// Returns true if the command was canceled.
+func (s *supervisor) exit(killOrphans bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.exited {
+		return s.canceled
+	}
+	s.exited = true
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	if killOrphans && s.canceled {
+		_ = killProcessGroup(s.cmd.Process)
+	}
+	return s.canceled
 }
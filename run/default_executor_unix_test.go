@@ -0,0 +1,155 @@
+//go:build !windows
+
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultExecutor_RunWithTimeout(t *testing.T) {
+	client := NewClient()
+
+	cmd := client.Command("sleep", "10").WithTimeout(50 * time.Millisecond)
+	start := time.Now()
+	err := cmd.Run()
+
+	var timeoutErr *TimeoutError
+	assert.ErrorAs(t, err, &timeoutErr)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, false, timeoutErr.Killed)
+	assert.Equal(t, syscall.SIGTERM, timeoutErr.Signal)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	var exitErr *ExitError
+	assert.False(t, errors.As(err, &exitErr), "should not be an ExitError")
+}
+
+func TestDefaultExecutor_RunWithTimeoutWhenSignalIgnored(t *testing.T) {
+	client := NewClient()
+
+	cmd := client.Command("sh", "-c", `trap "" TERM; echo ready; sleep 10`).
+		WithTimeout(200 * time.Millisecond).
+		WithGracePeriod(100 * time.Millisecond)
+	start := time.Now()
+	buf, err := cmd.Output()
+
+	var timeoutErr *TimeoutError
+	assert.ErrorAs(t, err, &timeoutErr)
+	assert.Equal(t, true, timeoutErr.Killed)
+	assert.Equal(t, "ready\n", string(buf))
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestDefaultExecutor_RunWithTimeoutKillsOrphans(t *testing.T) {
+	client := NewClient()
+
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	cmd := client.Command("sh", "-c", `sleep 30 & echo $! > "$0"; wait`, pidFile).
+		WithTimeout(200 * time.Millisecond).
+		WithTerminateSignal(syscall.SIGINT).
+		WithGracePeriod(100 * time.Millisecond)
+	err := cmd.Run()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	data, err := os.ReadFile(pidFile)
+	assert.NoError(t, err)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return !isProcessRunning(pid)
+	}, time.Second, 10*time.Millisecond)
+}
+
+// isProcessRunning returns true if pid exists and is not a zombie
+// (orphans may not be reaped promptly when running in a container).
+func isProcessRunning(pid int) bool {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return syscall.Kill(pid, 0) == nil
+	}
+	fields := strings.Fields(string(data))
+	return len(fields) > 2 && fields[2] != "Z"
+}
+
+func TestDefaultExecutor_RunWithContext(t *testing.T) {
+	client := NewClient()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cmd := client.CommandContext(ctx, "sleep", "10")
+	time.AfterFunc(50*time.Millisecond, cancel)
+	err := cmd.Run()
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorContains(t, err, "command canceled: sleep 10")
+}
+
+func TestDefaultExecutor_RunWithContextWhenCancelSet(t *testing.T) {
+	client := NewClient()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cmd := client.CommandContext(ctx, "sleep", "10")
+	orig := cmd.Cmd
+	canceled := make(chan struct{})
+	cmd.Cmd.Cancel = func() error {
+		close(canceled)
+		return nil
+	}
+	time.AfterFunc(50*time.Millisecond, cancel)
+	err := cmd.Run()
+
+	// The user's Cancel func is called, and the process still terminated.
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Same(t, orig, cmd.Cmd)
+	<-canceled
+}
+
+func TestCmd_ContextWhenRunDirectly(t *testing.T) {
+	client := NewClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// Executors that bypass the client still honor the context.
+	cmd := client.CommandContext(ctx, "sleep", "10")
+	start := time.Now()
+	err := cmd.Cmd.Run()
+	assert.EqualError(t, err, "signal: terminated")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestDefaultExecutor_RunWithTimeoutWhenCompleted(t *testing.T) {
+	client := NewClient()
+
+	cmd := client.Command("echo", "foo").WithTimeout(5 * time.Second)
+	buf, err := cmd.Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "foo\n", string(buf))
+	assert.Equal(t, 0, cmd.ExitCode())
+
+	cmd = client.Command("sh", "-c", "echo oops >&2; exit 3").WithTimeout(5 * time.Second)
+	_, err = cmd.Output()
+	var exitErr *exec.ExitError
+	assert.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, "oops\n", string(exitErr.Stderr))
+	assert.Equal(t, 3, cmd.ExitCode())
+}
+
+func TestDefaultExecutor_RunWithTimeoutWhenStartError(t *testing.T) {
+	client := NewClient()
+
+	cmd := client.Command("/does/not/exist").WithTimeout(5 * time.Second)
+	err := cmd.Run()
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
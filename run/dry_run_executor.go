@@ -0,0 +1,109 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/twelvelabs/termite/ui"
+)
+
+var (
+	_ Executor = &DryRunExecutor{}
+)
+
+// NewDryRunExecutor returns a new DryRunExecutor that prints to ios.
+func NewDryRunExecutor(ios *ui.IOStreams) *DryRunExecutor {
+	return &DryRunExecutor{
+		IOStreams: ios,
+		Executor:  DefaultExecutor,
+		Responder: StringResponse(""),
+	}
+}
+
+// DryRunExecutor is an implementation of Executor that prints commands
+// (in copy-pasteable, shell quoted form) to [ui.IOStreams.Err] rather than
+// running them. Each command responds with the output from Responder
+// and exits 0.
+//
+// Read-only commands that are safe to run for real (i.e. `git rev-parse`)
+// can be allowed via [DryRunExecutor.Allow].
+type DryRunExecutor struct {
+	// IOStreams is where commands are printed.
+	IOStreams *ui.IOStreams
+	// Executor is used to run allowed commands.
+	Executor Executor
+	// Responder returns the fake output for commands that are not allowed.
+	Responder Responder
+
+	mu      sync.Mutex
+	allowed []Matcher
+}
+
+// Allow registers matchers for commands that should be run for real.
+//
+// For example:
+//
+//	executor.Allow(MatchArgvPrefix("git", "rev-parse"))
+func (e *DryRunExecutor) Allow(matchers ...Matcher) *DryRunExecutor {
+	e.mu.Lock()
+	e.allowed = append(e.allowed, matchers...)
+	e.mu.Unlock()
+	return e
+}
+
+// IsAllowed returns true if cmd matches an allowed matcher.
+func (e *DryRunExecutor) IsAllowed(cmd *Cmd) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return MatchAnyOf(e.allowed...)(cmd)
+}
+
+func (e *DryRunExecutor) wrapped() Executor {
+	return e.Executor
+}
+
+func (e *DryRunExecutor) ExitCode(cmd *Cmd) int {
+	if e.IsAllowed(cmd) {
+		return e.Executor.ExitCode(cmd)
+	}
+	return cmd.exitCode
+}
+
+func (e *DryRunExecutor) Output(cmd *Cmd) ([]byte, error) {
+	if e.IsAllowed(cmd) {
+		return e.Executor.Output(cmd)
+	}
+	return captureOutput(cmd, e.Run)
+}
+
+func (e *DryRunExecutor) Run(cmd *Cmd) error {
+	if e.IsAllowed(cmd) {
+		return e.Executor.Run(cmd)
+	}
+
+	_, err := fmt.Fprintln(e.IOStreams.Err, cmd.ShellString())
+	if err != nil {
+		return err
+	}
+
+	stdout, stderr, err := e.Responder(cmd)
+	if cmd.Stdout != nil {
+		if _, we := cmd.Stdout.Write(stdout); we != nil {
+			return we
+		}
+	}
+	if cmd.Stderr != nil {
+		if _, we := cmd.Stderr.Write(stderr); we != nil {
+			return we
+		}
+	}
+
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		cmd.exitCode = exitErr.Code()
+	} else if err == nil {
+		cmd.exitCode = 0
+	}
+	return err
+}
@@ -0,0 +1,116 @@
+package run
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twelvelabs/termite/ui"
+)
+
+func TestNewDryRunExecutor(t *testing.T) {
+	ios := ui.NewTestIOStreams()
+	executor := NewDryRunExecutor(ios)
+	assert.Equal(t, ios, executor.IOStreams)
+	assert.Equal(t, DefaultExecutor, executor.Executor)
+	assert.NotNil(t, executor.Responder)
+}
+
+func TestDryRunExecutor_Run(t *testing.T) {
+	ios := ui.NewTestIOStreams()
+	executor := NewDryRunExecutor(ios)
+	client := &Client{Executor: executor}
+
+	cmd := client.Command("rm", "-rf", "/tmp/some dir")
+	cmd.Dir = "/tmp"
+	assert.Equal(t, -1, cmd.ExitCode())
+	err := cmd.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, cmd.ExitCode())
+
+	cmd = client.Command("git", "commit", "-m", "it's done")
+	buf, err := cmd.Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "", string(buf))
+
+	assert.Equal(t, []string{
+		"cd /tmp && rm -rf '/tmp/some dir'",
+		`git commit -m 'it'\''s done'`,
+	}, ios.Err.Lines())
+	assert.Equal(t, "", ios.Out.String())
+}
+
+func TestDryRunExecutor_RunWithResponder(t *testing.T) {
+	ios := ui.NewTestIOStreams()
+	executor := NewDryRunExecutor(ios)
+	client := &Client{Executor: executor}
+
+	executor.Responder = MuxResponse([]byte("fake out"), []byte("fake err"), 0)
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd := client.Command("kubectl", "apply", "-f", "-")
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	err := cmd.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, "fake out", stdout.String())
+	assert.Equal(t, "fake err", stderr.String())
+
+	executor.Responder = ErrorResponse(NewExitError(2))
+	cmd = client.Command("kubectl", "apply", "-f", "-")
+	err = cmd.Run()
+	assert.ErrorContains(t, err, "exit status 2")
+	assert.Equal(t, 2, cmd.ExitCode())
+}
+
+func TestDryRunExecutor_RunWhenWriteError(t *testing.T) {
+	ios := ui.NewTestIOStreams()
+	executor := NewDryRunExecutor(ios)
+	executor.Responder = MuxResponse([]byte("out"), []byte("err"), 0)
+	client := &Client{Executor: executor}
+
+	cmd := client.Command("echo")
+	cmd.Stdout = &brokenWriter{err: assert.AnError}
+	assert.ErrorIs(t, cmd.Run(), assert.AnError)
+
+	cmd = client.Command("echo")
+	cmd.Stderr = &brokenWriter{err: assert.AnError}
+	assert.ErrorIs(t, cmd.Run(), assert.AnError)
+
+	ios.Err = &brokenStream{IOStream: ios.Err, err: assert.AnError}
+	assert.ErrorIs(t, client.Command("echo").Run(), assert.AnError)
+}
+
+func TestDryRunExecutor_Allow(t *testing.T) {
+	ios := ui.NewTestIOStreams()
+	executor := NewDryRunExecutor(ios).Allow(
+		MatchArgvPrefix("echo", "allowed"),
+	)
+	client := &Client{Executor: executor}
+
+	cmd := client.Command("echo", "allowed")
+	buf, err := cmd.Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "allowed\n", string(buf))
+	assert.Equal(t, 0, cmd.ExitCode())
+
+	cmd = client.Command("echo", "allowed", "again")
+	assert.NoError(t, cmd.Run())
+
+	cmd = client.Command("echo", "not-allowed")
+	buf, err = cmd.Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "", string(buf))
+
+	assert.Equal(t, []string{"echo not-allowed"}, ios.Err.Lines())
+}
+
+type brokenStream struct {
+	ui.IOStream
+	err error
+}
+
+func (s *brokenStream) Write(p []byte) (int, error) {
+	return 0, s.err
+}
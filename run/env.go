@@ -0,0 +1,330 @@
+package run
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Redacted replaces secret values in debug output, logs, and fixtures.
+	Redacted = "[REDACTED]"
+)
+
+var (
+	envKeyRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+)
+
+// envOp modifies a command environment.
+type envOp func(env []string) ([]string, error)
+
+// WithEnv sets the environment variable key to value,
+// overlaying the inherited environment.
+func (c *Cmd) WithEnv(key string, value string) *Cmd {
+	c.envOps = append(c.envOps, func(env []string) ([]string, error) {
+		return envSet(env, key, value), nil
+	})
+	return c
+}
+
+// WithSecretEnv is like [Cmd.WithEnv], but also marks value as a secret
+// (see [Cmd.WithSecret]).
+func (c *Cmd) WithSecretEnv(key string, value string) *Cmd {
+	return c.WithSecret(value).WithEnv(key, value)
+}
+
+// WithSecret marks values as secrets. Secrets are redacted from
+// [Cmd.DebugString], [Cmd.ShellString], errors, and recorded fixtures.
+func (c *Cmd) WithSecret(values ...string) *Cmd {
+	for _, v := range values {
+		if v != "" {
+			c.secrets = append(c.secrets, v)
+		}
+	}
+	return c
+}
+
+// WithoutEnv removes the environment variables matching patterns.
+// Patterns may contain `*` wildcards (i.e. "AWS_*").
+func (c *Cmd) WithoutEnv(patterns ...string) *Cmd {
+	matches := envMatcher(patterns)
+	c.envOps = append(c.envOps, func(env []string) ([]string, error) {
+		return envFilter(env, func(key string) bool { return !matches(key) }), nil
+	})
+	return c
+}
+
+// WithHermeticEnv removes all inherited environment variables except those
+// matching the allow-list patterns. Patterns may contain `*` wildcards.
+// Variables set afterwards (i.e. via [Cmd.WithEnv]) are unaffected.
+//
+// For example:
+//
+//	cmd.WithHermeticEnv("PATH", "HOME", "LC_*").WithEnv("CI", "true")
+func (c *Cmd) WithHermeticEnv(allow ...string) *Cmd {
+	matches := envMatcher(allow)
+	c.hermetic = true
+	c.envOps = append(c.envOps, func(env []string) ([]string, error) {
+		return envFilter(env, matches), nil
+	})
+	return c
+}
+
+// WithInheritedEnv resets the command to inherit the environment of the
+// current process, discarding any previous environment changes.
+func (c *Cmd) WithInheritedEnv() *Cmd {
+	c.Env = nil
+	c.envOps = nil
+	c.hermetic = false
+	return c
+}
+
+// WithEnvFile overlays the variables defined in the given `.env` files.
+// Errors reading or parsing the files are returned when the command is run.
+// See [ReadEnvFile] for the supported syntax.
+func (c *Cmd) WithEnvFile(paths ...string) *Cmd {
+	for _, path := range paths {
+		c.envOps = append(c.envOps, func(env []string) ([]string, error) {
+			vars, err := ReadEnvFile(path)
+			if err != nil {
+				return env, err
+			}
+			for _, kv := range vars {
+				k, v, _ := strings.Cut(kv, "=")
+				env = envSet(env, k, v)
+			}
+			return env, nil
+		})
+	}
+	return c
+}
+
+// Environ returns a copy of the environment in which the command would be run
+// as it is currently configured (including any changes made via the
+// `With*Env` methods).
+func (c *Cmd) Environ() []string {
+	env, _ := c.environ()
+	return env
+}
+
+// Redact replaces all secret values in s with [Redacted].
+func (c *Cmd) Redact(s string) string {
+	if len(c.secrets) == 0 {
+		return s
+	}
+	// Replace longest first in case secrets overlap.
+	secrets := append([]string{}, c.secrets...)
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(secrets[i]) > len(secrets[j])
+	})
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	return s
+}
+
+// redactAll returns a copy of values with all secrets redacted.
+func (c *Cmd) redactAll(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	redacted := make([]string, len(values))
+	for i, v := range values {
+		redacted[i] = c.Redact(v)
+	}
+	return redacted
+}
+
+func (c *Cmd) environ() ([]string, error) {
+	env := c.Cmd.Environ()
+	for _, op := range c.envOps {
+		var err error
+		if env, err = op(env); err != nil {
+			return env, err
+		}
+	}
+	return env, nil
+}
+
+// prepare applies any pending environment changes to the underlying command.
+func (c *Cmd) prepare() error {
+	if len(c.envOps) == 0 {
+		return nil
+	}
+	env, err := c.environ()
+	if err != nil {
+		return err
+	}
+	c.Env = env
+	c.envOps = nil
+	return nil
+}
+
+// ReadEnvFile reads the `.env` file at path and returns its variables
+// as "key=value" strings. The supported syntax is:
+//
+//	# comments and blank lines are ignored
+//	export KEY=value          # "export" prefixes are optional
+//	KEY="double quoted\tvalue" # supports \n, \t, \", and \\ escapes
+//	KEY='single quoted value' # taken literally
+func ReadEnvFile(path string) ([]string, error) {
+	data, err := osReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !envKeyRegexp.MatchString(key) {
+			return nil, fmt.Errorf("%s:%d: invalid line: %q", path, lineNum, line)
+		}
+		value, err = parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNum, err)
+		}
+		vars = append(vars, key+"="+value)
+	}
+	return vars, nil
+}
+
+func parseEnvValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		end := strings.LastIndex(value, `"`)
+		if end == 0 {
+			return "", fmt.Errorf("unterminated quoted value: %s", value)
+		}
+		unquoted, err := strconv.Unquote(value[:end+1])
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted value: %s", value)
+		}
+		return unquoted, nil
+	case strings.HasPrefix(value, "'"):
+		end := strings.LastIndex(value, "'")
+		if end == 0 {
+			return "", fmt.Errorf("unterminated quoted value: %s", value)
+		}
+		return value[1:end], nil
+	default:
+		// Strip trailing comments from unquoted values.
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		return value, nil
+	}
+}
+
+// envSet returns env with key set to value (replacing any existing entries).
+func envSet(env []string, key string, value string) []string {
+	env = envFilter(env, func(k string) bool { return k != key })
+	return append(env, key+"="+value)
+}
+
+// envFilter returns the entries in env whose keys satisfy keep.
+func envFilter(env []string, keep func(key string) bool) []string {
+	filtered := []string{}
+	for _, kv := range env {
+		k, _, _ := strings.Cut(kv, "=")
+		if keep(k) {
+			filtered = append(filtered, kv)
+		}
+	}
+	return filtered
+}
+
+// envMatcher returns a func that matches keys against glob patterns.
+func envMatcher(patterns []string) func(key string) bool {
+	regexps := make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		regexps[i] = globToRegexp(p)
+	}
+	return func(key string) bool {
+		for _, r := range regexps {
+			if r.MatchString(key) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// envMap converts a slice of "key=value" strings into a map.
+// Later entries win, matching the behavior of [os/exec].
+func envMap(environ []string) map[string]string {
+	m := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		k, v, _ := strings.Cut(kv, "=")
+		m[k] = v
+	}
+	return m
+}
+
+// envDiff returns the variables in cmd's environment that were added or
+// changed relative to the current process, and those that were removed.
+// Commands that inherit the environment have no diff.
+func envDiff(cmd *Cmd) (map[string]string, []string) {
+	if cmd.Env == nil && len(cmd.envOps) == 0 {
+		return nil, nil
+	}
+	base := envMap(os.Environ())
+	env := envMap(cmd.Environ())
+	if cmd.Dir != "" {
+		// PWD follows Dir and isn't an interesting change.
+		if v, ok := base["PWD"]; ok {
+			env["PWD"] = v
+		} else {
+			delete(env, "PWD")
+		}
+	}
+
+	set := map[string]string{}
+	for k, v := range env {
+		if bv, ok := base[k]; !ok || bv != v {
+			set[k] = cmd.Redact(v)
+		}
+	}
+	unset := []string{}
+	for k := range base {
+		if _, ok := env[k]; !ok {
+			unset = append(unset, k)
+		}
+	}
+	sort.Strings(unset)
+
+	if len(set) == 0 {
+		set = nil
+	}
+	if len(unset) == 0 {
+		unset = nil
+	}
+	return set, unset
+}
+
+// hermeticEnv returns the (redacted) environment of the command.
+func hermeticEnv(cmd *Cmd) map[string]string {
+	env := envMap(cmd.Environ())
+	if cmd.Dir != "" {
+		// PWD follows Dir.
+		delete(env, "PWD")
+	}
+	for k, v := range env {
+		env[k] = cmd.Redact(v)
+	}
+	return env
+}
@@ -0,0 +1,176 @@
+package run
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCmd_WithEnv(t *testing.T) {
+	t.Setenv("ENV_TEST_INHERITED", "1")
+
+	cmd := NewClient().Command("env").
+		WithEnv("ENV_TEST_FOO", "foo").
+		WithEnv("ENV_TEST_FOO", "bar")
+	env := envMap(cmd.Environ())
+	assert.Equal(t, "bar", env["ENV_TEST_FOO"])
+	assert.Equal(t, "1", env["ENV_TEST_INHERITED"])
+
+	buf, err := cmd.Output()
+	assert.NoError(t, err)
+	assert.Contains(t, string(buf), "ENV_TEST_FOO=bar\n")
+	assert.Contains(t, string(buf), "ENV_TEST_INHERITED=1\n")
+	assert.NotContains(t, string(buf), "ENV_TEST_FOO=foo\n")
+}
+
+func TestCmd_WithoutEnv(t *testing.T) {
+	t.Setenv("ENV_TEST_AWS_KEY", "1")
+	t.Setenv("ENV_TEST_AWS_SECRET", "2")
+	t.Setenv("ENV_TEST_KEEP", "3")
+
+	cmd := NewClient().Command("env").WithoutEnv("ENV_TEST_AWS_*")
+	env := envMap(cmd.Environ())
+	assert.NotContains(t, env, "ENV_TEST_AWS_KEY")
+	assert.NotContains(t, env, "ENV_TEST_AWS_SECRET")
+	assert.Equal(t, "3", env["ENV_TEST_KEEP"])
+}
+
+func TestCmd_WithHermeticEnv(t *testing.T) {
+	t.Setenv("ENV_TEST_KEEP", "1")
+	t.Setenv("ENV_TEST_DROP", "2")
+
+	cmd := NewClient().Command("env").
+		WithHermeticEnv("PATH", "ENV_TEST_KEEP").
+		WithEnv("CI", "true")
+	env := envMap(cmd.Environ())
+	assert.Equal(t, map[string]string{
+		"PATH":          os.Getenv("PATH"),
+		"ENV_TEST_KEEP": "1",
+		"CI":            "true",
+	}, env)
+
+	buf, err := cmd.Output()
+	assert.NoError(t, err)
+	assert.NotContains(t, string(buf), "ENV_TEST_DROP")
+}
+
+func TestCmd_WithInheritedEnv(t *testing.T) {
+	cmd := NewClient().Command("env").WithHermeticEnv().WithInheritedEnv()
+	assert.Equal(t, os.Environ(), cmd.Environ())
+
+	cmd = NewClient().Command("env")
+	cmd.Env = []string{"FOO=bar"}
+	cmd.WithInheritedEnv()
+	assert.Nil(t, cmd.Env)
+}
+
+func TestCmd_WithEnvFile(t *testing.T) {
+	cmd := NewClient().Command("env").
+		WithEnvFile(filepath.Join("testdata", "test.env")).
+		WithEnv("APP_NAME", "override")
+	env := envMap(cmd.Environ())
+	assert.Equal(t, "override", env["APP_NAME"])
+	assert.Equal(t, "hello\tworld", env["APP_GREETING"])
+	assert.Equal(t, "single $quoted", env["APP_QUOTED"])
+	assert.Equal(t, "some value", env["APP_UNQUOTED"])
+
+	cmd = NewClient().Command("env").WithEnvFile(filepath.Join("testdata", "invalid.env"))
+	err := cmd.Run()
+	assert.ErrorContains(t, err, `invalid.env:2: invalid line: "this is not valid"`)
+
+	cmd = NewClient().Command("env").WithEnvFile(filepath.Join("testdata", "nope.env"))
+	_, err = cmd.Output()
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	cmd = NewClient().Command("env").WithEnvFile(filepath.Join("testdata", "nope.env"))
+	err = DefaultExecutor.Run(cmd)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = DefaultExecutor.Output(cmd)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestReadEnvFile_Errors(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		`KEY="unterminated`:   "unterminated quoted value",
+		`KEY='unterminated`:   "unterminated quoted value",
+		`KEY="bad \q escape"`: "invalid quoted value",
+		`1KEY=value`:          "invalid line",
+	}
+	for content, expected := range tests {
+		path := filepath.Join(dir, ".env")
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		_, err := ReadEnvFile(path)
+		assert.ErrorContains(t, err, expected, content)
+	}
+}
+
+func TestCmd_WithSecret(t *testing.T) {
+	cmd := NewClient().Command("my-curl", "-H", "Authorization: Bearer s3cr3t-token").
+		WithSecret("s3cr3t", "s3cr3t-token", "").
+		WithSecretEnv("API_TOKEN", "another-secret")
+	cmd.Stdin = bytes.NewBufferString("another-secret")
+
+	assert.Equal(t, "my-curl -H Authorization: Bearer [REDACTED] [Stdin: \"[REDACTED]\"]", cmd.DebugString())
+	assert.Contains(t, cmd.ShellString(), "API_TOKEN='[REDACTED]'")
+	assert.NotContains(t, cmd.ShellString(), "secret")
+	assert.Equal(t, "another-secret", envMap(cmd.Environ())["API_TOKEN"])
+	assert.Equal(t, "nothing to see", cmd.Redact("nothing to see"))
+}
+
+func TestCmd_WithSecret_Recording(t *testing.T) {
+	executor := NewRecordingExecutor(nil)
+	client := &Client{Executor: executor}
+
+	cmd := client.Command("sh", "-c", `echo "token=$API_TOKEN" >&2; echo "$1"; exit 1`, "sh", "hunter2").
+		WithSecretEnv("API_TOKEN", "hunter2")
+	_, err := cmd.Output()
+	assert.Error(t, err)
+
+	rec := executor.Recordings[0]
+	assert.Equal(t, "[REDACTED]", rec.Args[4])
+	assert.Equal(t, "[REDACTED]", rec.Env["API_TOKEN"])
+	assert.Equal(t, "[REDACTED]\n", rec.Stdout)
+	assert.Equal(t, "token=[REDACTED]\n", rec.Stderr)
+}
+
+func TestMatchEnv_SeesEffectiveEnv(t *testing.T) {
+	client := NewClient().WithStubbing()
+	defer client.VerifyStubs(t)
+
+	client.RegisterStub(
+		MatchAll(MatchName("deploy"), MatchEnv("STAGE", "prod"), Not(MatchEnvPresent("HOME"))),
+		StringResponse("ok"),
+	)
+
+	buf, err := client.Command("deploy").
+		WithHermeticEnv("PATH").
+		WithEnv("STAGE", "prod").
+		Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", string(buf))
+}
+
+func TestEnvDiff(t *testing.T) {
+	t.Setenv("ENV_TEST_REMOVED", "1")
+	t.Setenv("ENV_TEST_CHANGED", "1")
+
+	cmd := NewClient().Command("env")
+	set, unset := envDiff(cmd)
+	assert.Nil(t, set)
+	assert.Nil(t, unset)
+
+	cmd.Dir = os.TempDir()
+	cmd.WithoutEnv("ENV_TEST_REMOVED").WithEnv("ENV_TEST_CHANGED", "2")
+	set, unset = envDiff(cmd)
+	assert.Equal(t, map[string]string{"ENV_TEST_CHANGED": "2"}, set)
+	assert.Equal(t, []string{"ENV_TEST_REMOVED"}, unset)
+
+	cmd = NewClient().Command("env").WithEnv("ENV_TEST_CHANGED", "1")
+	set, unset = envDiff(cmd)
+	assert.Nil(t, set)
+	assert.Nil(t, unset)
+}
@@ -1,16 +1,66 @@
 package run
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/twelvelabs/termite/ui"
+)
+
+const (
+	// MaxStderrTail is the maximum number of bytes of stderr kept in an [ExitError].
+	MaxStderrTail = 4096
 )
 
+// ExitErrorOpt allows setting optional [ExitError] fields.
+type ExitErrorOpt func(e *ExitError)
+
+// WithExitArgs sets the command line arguments of an exit error.
+func WithExitArgs(args ...string) ExitErrorOpt {
+	return func(e *ExitError) {
+		e.Args = args
+	}
+}
+
+// WithExitDir sets the working dir of an exit error.
+func WithExitDir(dir string) ExitErrorOpt {
+	return func(e *ExitError) {
+		e.Dir = dir
+	}
+}
+
+// WithExitStderr sets the stderr tail of an exit error.
+func WithExitStderr(stderr string) ExitErrorOpt {
+	return func(e *ExitError) {
+		e.StderrTail = tail(stderr, MaxStderrTail)
+	}
+}
+
+// WithExitDuration sets the duration of an exit error.
+func WithExitDuration(d time.Duration) ExitErrorOpt {
+	return func(e *ExitError) {
+		e.Duration = d
+	}
+}
+
 // NewExitError returns a new exit error for code.
-func NewExitError(code int) *ExitError {
-	return &ExitError{
+func NewExitError(code int, opts ...ExitErrorOpt) *ExitError {
+	e := &ExitError{
 		ExitError: &exec.ExitError{},
 		code:      code,
 	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
 }
 
 // ExitError is a light wrapper for [exec.ExitError] that allows for easily accessing
@@ -19,8 +69,24 @@ func NewExitError(code int) *ExitError {
 // The reason we don't use [exec.ExitError] directly is that it stores the
 // exit code inside `os.ProcessState`, which in turn uses `syscall.WaitStatus`,
 // and WaitStatus is implemented differently per-system.
+//
+// Exit errors returned by [Cmd.Run] and [Cmd.Output] include the
+// command context (args, dir, duration, and the tail of stderr)
+// so that failures are easier to diagnose. See [RenderExitError].
 type ExitError struct {
 	*exec.ExitError
+
+	// Args are the command line arguments (with secrets redacted).
+	Args []string
+	// Dir is the working directory of the command.
+	Dir string
+	// StderrTail is the last [MaxStderrTail] bytes written to stderr
+	// (with secrets redacted). Stderr written directly to a file
+	// (i.e. the terminal) is not captured.
+	StderrTail string
+	// Duration is how long the command ran.
+	Duration time.Duration
+
 	code int
 }
 
@@ -34,6 +100,215 @@ func (e *ExitError) Code() int {
 }
 
 // Error returns the error message.
+// When known, the message is prefixed with the command and
+// suffixed with the last line of stderr.
 func (e *ExitError) Error() string {
-	return fmt.Sprintf("exit status %v", e.Code())
+	msg := fmt.Sprintf("exit status %v", e.Code())
+	if len(e.Args) > 0 {
+		msg = strings.Join(e.Args, " ") + ": " + msg
+	}
+	if line := lastLine(e.StderrTail); line != "" {
+		msg += ": " + line
+	}
+	return msg
+}
+
+// RenderExitError prints a formatted failure block for err to the
+// stderr of u, for example:
+//
+//	✖ Command failed with exit status 128 after 1.2s
+//	  $ git push origin main
+//	  in /src/app
+//
+//	  fatal: 'origin' does not appear to be a git repository
+//
+// Returns false (and prints nothing) if err is not an [ExitError].
+func RenderExitError(u *ui.UserInterface, err error) bool {
+	var e *ExitError
+	if !errors.As(err, &e) {
+		return false
+	}
+
+	summary := fmt.Sprintf("Command failed with exit status %d", e.Code())
+	if e.Duration > 0 {
+		summary += fmt.Sprintf(" after %v", e.Duration.Round(time.Millisecond))
+	}
+	u.Err("%s %s\n", u.FailureIcon(), u.Bold(summary))
+	if len(e.Args) > 0 {
+		quoted := make([]string, 0, len(e.Args))
+		for _, arg := range e.Args {
+			quoted = append(quoted, shellQuote(arg))
+		}
+		u.Err("  %s\n", u.Gray("$ "+strings.Join(quoted, " ")))
+	}
+	if e.Dir != "" {
+		u.Err("  %s\n", u.Gray("in "+e.Dir))
+	}
+	if stderr := strings.TrimRight(e.StderrTail, "\n"); stderr != "" {
+		u.Err("\n")
+		for _, line := range strings.Split(stderr, "\n") {
+			u.Err("  %s\n", line)
+		}
+	}
+	return true
+}
+
+// NewTimeoutError returns a new timeout error for a command
+// that exceeded timeout. Intended for use when stubbing.
+func NewTimeoutError(timeout time.Duration) *TimeoutError {
+	return &TimeoutError{
+		Timeout: timeout,
+		Err:     context.DeadlineExceeded,
+	}
+}
+
+// TimeoutError is returned when a command is terminated because
+// it exceeded its timeout or its context was canceled.
+//
+// Err is the underlying context error, so both
+// `errors.Is(err, context.DeadlineExceeded)` and
+// `errors.Is(err, context.Canceled)` work as expected.
+type TimeoutError struct {
+	// Args are the command line arguments of the terminated command.
+	Args []string
+	// Timeout is the timeout configured for the command (if any).
+	Timeout time.Duration
+	// Signal is the signal that was sent to terminate the command.
+	Signal os.Signal
+	// Killed is true if the command failed to exit within the grace period
+	// and had to be killed.
+	Killed bool
+	// Err is the context error that triggered termination.
+	Err error
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Error returns the error message.
+func (e *TimeoutError) Error() string {
+	var msg string
+	switch {
+	case errors.Is(e.Err, context.Canceled):
+		msg = "command canceled"
+	case e.Timeout > 0:
+		msg = fmt.Sprintf("command timed out after %v", e.Timeout)
+	default:
+		msg = "command timed out"
+	}
+	if len(e.Args) > 0 {
+		msg += ": " + strings.Join(e.Args, " ")
+	}
+	return msg
+}
+
+// exitError enriches err with the command context if it is an exit error.
+// Stubbed exit errors are copied rather than modified, since a responder
+// may return the same error for many commands.
+func (c *Cmd) exitError(err error, start time.Time, stderr *tailBuffer) error {
+	var e *ExitError
+	var stubbed *ExitError
+	var execErr *exec.ExitError
+	switch {
+	case errors.As(err, &stubbed):
+		copied := *stubbed
+		e = &copied
+	case errors.As(err, &execErr):
+		e = &ExitError{ExitError: execErr, code: execErr.ExitCode()}
+	default:
+		return err
+	}
+
+	if e.Args == nil {
+		e.Args = c.redactAll(c.Args)
+	}
+	if e.Dir == "" {
+		e.Dir = c.Dir
+	}
+	if e.Duration == 0 {
+		e.Duration = time.Since(start)
+		if d := time.Duration(c.stubDuration.Load()); d > 0 {
+			e.Duration = d
+		}
+	}
+	if e.StderrTail == "" {
+		data := e.ExitError.Stderr
+		if stderr != nil {
+			data = stderr.Bytes()
+		}
+		e.StderrTail = tail(c.Redact(string(data)), MaxStderrTail)
+	}
+	return e
+}
+
+// tailStderr tees the command stderr into a bounded buffer so it can be
+// included in exit errors. Returns a func that restores the original stderr.
+//
+// Stderr connected directly to a file (i.e. the terminal) is left alone,
+// as is stderr for [Cmd.Output], which is already captured in the
+// [exec.ExitError].
+func (c *Cmd) tailStderr(output bool) (*tailBuffer, func()) {
+	orig := c.Stderr
+	restore := func() {
+		c.Stderr = orig
+	}
+	if c.pty != nil {
+		return nil, restore
+	}
+	buf := &tailBuffer{size: MaxStderrTail}
+	switch orig.(type) {
+	case nil:
+		if output {
+			return nil, restore
+		}
+		c.Stderr = buf
+	case *os.File:
+		return nil, restore
+	default:
+		c.Stderr = io.MultiWriter(orig, buf)
+	}
+	return buf, restore
+}
+
+// tailBuffer is a writer that keeps the last size bytes written to it.
+type tailBuffer struct {
+	mu   sync.Mutex
+	size int
+	buf  []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.size {
+		b.buf = append([]byte{}, b.buf[len(b.buf)-b.size:]...)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte{}, b.buf...)
+}
+
+// tail returns the last n bytes of s (or fewer, so that a multi-byte
+// character isn't split).
+func tail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	i := len(s) - n
+	for i < len(s) && !utf8.RuneStart(s[i]) {
+		i++
+	}
+	return s[i:]
+}
+
+// lastLine returns the last non-blank line of s.
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
 }
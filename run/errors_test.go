@@ -1,11 +1,19 @@
 package run
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"os"
 	"os/exec"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/twelvelabs/termite/ui"
 )
 
 func TestNewExitError(t *testing.T) {
@@ -25,4 +33,164 @@ func TestExitError_Code(t *testing.T) {
 func TestExitError_Error(t *testing.T) {
 	err := NewExitError(12)
 	assert.Equal(t, "exit status 12", err.Error())
+
+	err = NewExitError(128,
+		WithExitArgs("git", "push"),
+		WithExitStderr("remote: something\nfatal: no remote\n\n"),
+	)
+	assert.Equal(t, "git push: exit status 128: fatal: no remote", err.Error())
+}
+
+func TestNewExitError_WithOpts(t *testing.T) {
+	err := NewExitError(1,
+		WithExitArgs("git", "status"),
+		WithExitDir("/src"),
+		WithExitStderr(strings.Repeat("x", MaxStderrTail)+"fatal"),
+		WithExitDuration(time.Second),
+	)
+	assert.Equal(t, 1, err.Code())
+	assert.Equal(t, []string{"git", "status"}, err.Args)
+	assert.Equal(t, "/src", err.Dir)
+	assert.Len(t, err.StderrTail, MaxStderrTail)
+	assert.True(t, strings.HasSuffix(err.StderrTail, "fatal"))
+	assert.Equal(t, time.Second, err.Duration)
+}
+
+func TestCmd_ExitError(t *testing.T) {
+	client := NewClient()
+
+	// Run w/ unset stderr
+	cmd := client.Command("sh", "-c", "echo oops >&2; exit 3")
+	cmd.Dir = "/tmp"
+	err := cmd.Run()
+	var exitErr *ExitError
+	assert.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 3, exitErr.Code())
+	assert.Equal(t, []string{"sh", "-c", "echo oops >&2; exit 3"}, exitErr.Args)
+	assert.Equal(t, "/tmp", exitErr.Dir)
+	assert.Equal(t, "oops\n", exitErr.StderrTail)
+	assert.Greater(t, exitErr.Duration, time.Duration(0))
+	assert.Equal(t, "sh -c echo oops >&2; exit 3: exit status 3: oops", err.Error())
+	assert.Nil(t, cmd.Stderr)
+
+	// Should still be a wrapper around std lib exit error
+	var execErr *exec.ExitError
+	assert.ErrorAs(t, err, &execErr)
+
+	// Output
+	cmd = client.Command("sh", "-c", "echo token=s3cr3t >&2; exit 4").WithSecret("s3cr3t")
+	_, err = cmd.Output()
+	assert.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 4, exitErr.Code())
+	assert.Equal(t, "token="+Redacted+"\n", exitErr.StderrTail)
+	assert.Equal(t, "token=s3cr3t\n", string(exitErr.Stderr))
+
+	// Run w/ custom stderr
+	stderr := &bytes.Buffer{}
+	cmd = client.Command("sh", "-c", "echo oops >&2; exit 5")
+	cmd.Stderr = stderr
+	err = cmd.Run()
+	assert.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, "oops\n", exitErr.StderrTail)
+	assert.Equal(t, "oops\n", stderr.String())
+	assert.Equal(t, stderr, cmd.Stderr)
+
+	// Run w/ stderr attached to a file.
+	f, _ := os.CreateTemp(t.TempDir(), "stderr")
+	defer f.Close()
+	cmd = client.Command("sh", "-c", "echo oops >&2; exit 6")
+	cmd.Stderr = f
+	err = cmd.Run()
+	assert.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, "", exitErr.StderrTail)
+}
+
+func TestCmd_ExitError_WhenStubbed(t *testing.T) {
+	stubErr := NewExitError(2)
+	executor := NewStubExecutor()
+	executor.RegisterStub(MatchAny, func(cmd *Cmd) ([]byte, []byte, error) {
+		return nil, []byte("bad flag\n"), stubErr
+	})
+	executor.RegisterStub(MatchAny, WithDuration(time.Second, ErrorResponse(stubErr)))
+	client := &Client{Executor: executor}
+
+	cmd := client.Command("git", "--nope")
+	err := cmd.Run()
+	var exitErr *ExitError
+	assert.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, "git --nope: exit status 2: bad flag", err.Error())
+
+	_, err = client.Command("git", "--other").Output()
+	assert.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, []string{"git", "--other"}, exitErr.Args)
+	assert.Equal(t, time.Second, exitErr.Duration)
+
+	// The stubbed error should not be modified.
+	assert.Nil(t, stubErr.Args)
+	assert.Equal(t, "exit status 2", stubErr.Error())
+}
+
+func TestCmd_ExitError_WhenWrapped(t *testing.T) {
+	execErr := exec.Command("sh", "-c", "exit 7").Run()
+	executor := NewStubExecutor()
+	executor.RegisterStub(MatchAny, ErrorResponse(fmt.Errorf("sandbox: %w", execErr)))
+	client := &Client{Executor: executor}
+
+	err := client.Command("make", "test").Run()
+	var exitErr *ExitError
+	assert.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 7, exitErr.Code())
+	assert.Equal(t, []string{"make", "test"}, exitErr.Args)
+}
+
+func TestTail(t *testing.T) {
+	assert.Equal(t, "abc", tail("abc", 5))
+	assert.Equal(t, "bc", tail("abc", 2))
+	// Multi-byte characters aren't split.
+	assert.Equal(t, "é", tail("aéé", 3))
+}
+
+func TestRenderExitError(t *testing.T) {
+	ios := ui.NewTestIOStreams()
+	u := ui.NewUserInterface(ios)
+
+	assert.False(t, RenderExitError(u, errors.New("boom")))
+	assert.Equal(t, "", ios.Err.String())
+
+	err := fmt.Errorf("push failed: %w", NewExitError(128,
+		WithExitArgs("git", "push", "my remote"),
+		WithExitDir("/src/app"),
+		WithExitStderr("fatal: no remote\nfatal: try again\n"),
+		WithExitDuration(1234*time.Millisecond),
+	))
+	assert.True(t, RenderExitError(u, err))
+	assert.Equal(t, []string{
+		"✖ Command failed with exit status 128 after 1.234s",
+		"  $ git push 'my remote'",
+		"  in /src/app",
+		"",
+		"  fatal: no remote",
+		"  fatal: try again",
+	}, ios.Err.Lines())
+}
+
+func TestNewTimeoutError(t *testing.T) {
+	err := NewTimeoutError(2 * time.Second)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, "command timed out after 2s", err.Error())
+}
+
+func TestTimeoutError_Error(t *testing.T) {
+	err := &TimeoutError{
+		Args: []string{"sleep", "10"},
+		Err:  context.DeadlineExceeded,
+	}
+	assert.Equal(t, "command timed out: sleep 10", err.Error())
+
+	err.Timeout = time.Second
+	assert.Equal(t, "command timed out after 1s: sleep 10", err.Error())
+
+	err.Err = context.Canceled
+	assert.Equal(t, "command canceled: sleep 10", err.Error())
+	assert.ErrorIs(t, err, context.Canceled)
 }
@@ -1,5 +1,11 @@
 package run
 
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+)
+
 //go:generate moq --rm --out=executor_test.go . Executor
 
 // Executor is an interface representing the ability to execute an external command.
@@ -12,3 +18,31 @@ type Executor interface {
 	// Run starts the specified command and waits for it to complete.
 	Run(cmd *Cmd) error
 }
+
+// captureOutput is a helper for implementing Executor.Output in terms of run.
+// Mirrors [os/exec.Cmd.Output]: stdout is returned and, unless Stderr is
+// already set, stderr is captured into any returned exit error.
+func captureOutput(cmd *Cmd, run func(cmd *Cmd) error) ([]byte, error) {
+	if cmd.Stdout != nil {
+		return nil, errors.New("run: Stdout already set")
+	}
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+
+	cmd.Stdout = &stdout
+
+	captureErr := cmd.Stderr == nil
+	if captureErr {
+		cmd.Stderr = &stderr
+	}
+
+	err := run(cmd)
+	if err != nil && captureErr {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitErr.Stderr = stderr.Bytes()
+		}
+	}
+	return stdout.Bytes(), err
+}
@@ -0,0 +1,98 @@
+package run
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+var (
+	// for stubbing
+	osReadFile  = os.ReadFile
+	osWriteFile = os.WriteFile
+)
+
+// Fixture is a collection of recorded command executions.
+// See [RecordingExecutor] and [NewReplayExecutor].
+type Fixture struct {
+	Recordings []*Recording `yaml:"recordings"`
+}
+
+// Recording is a single recorded command execution.
+type Recording struct {
+	// Args are the command line arguments, including the command name.
+	Args []string `yaml:"args"`
+	// Dir is the working directory of the command.
+	Dir string `yaml:"dir,omitempty"`
+	// Env contains the variables added or changed relative to the parent process.
+	Env map[string]string `yaml:"env,omitempty"`
+	// EnvUnset contains the variables removed relative to the parent process.
+	EnvUnset []string `yaml:"env_unset,omitempty"`
+	// Stdin is the data passed to the command via stdin.
+	Stdin string `yaml:"stdin,omitempty"`
+	// Stdout is the data written by the command to stdout.
+	Stdout string `yaml:"stdout,omitempty"`
+	// Stderr is the data written by the command to stderr.
+	Stderr string `yaml:"stderr,omitempty"`
+	// ExitCode is the exit code of the command.
+	ExitCode int `yaml:"exit_code"`
+	// Error is set when the command could not be run (i.e. it was not found).
+	Error string `yaml:"error,omitempty"`
+	// Duration is how long the command took to run.
+	Duration time.Duration `yaml:"duration"`
+}
+
+// Matcher returns a matcher for commands with the same args and stdin
+// as the recording.
+func (r *Recording) Matcher() Matcher {
+	return MatchAll(
+		MatchArgv(r.Args...),
+		MatchStdin(r.Stdin),
+	)
+}
+
+// Responder returns a responder that replays the recorded output.
+func (r *Recording) Responder() Responder {
+	if r.Error != "" {
+		return ErrorResponse(errors.New(r.Error))
+	}
+	return MuxResponse([]byte(r.Stdout), []byte(r.Stderr), r.ExitCode)
+}
+
+// ReadFixture reads the fixture file at path.
+func ReadFixture(path string) (*Fixture, error) {
+	data, err := osReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fixture := &Fixture{}
+	if err := yaml.Unmarshal(data, fixture); err != nil {
+		return nil, err
+	}
+	return fixture, nil
+}
+
+// WriteFixture writes fixture to path.
+func WriteFixture(path string, fixture *Fixture) error {
+	data, err := yaml.Marshal(fixture)
+	if err != nil {
+		return err
+	}
+	return osWriteFile(path, data, 0644)
+}
+
+// NewReplayExecutor returns a [StubExecutor] with stubs registered
+// for each of the recordings in the fixture file at path.
+func NewReplayExecutor(path string) (*StubExecutor, error) {
+	fixture, err := ReadFixture(path)
+	if err != nil {
+		return nil, err
+	}
+	executor := NewStubExecutor()
+	for _, r := range fixture.Recordings {
+		executor.RegisterStub(r.Matcher(), r.Responder())
+	}
+	return executor, nil
+}
@@ -0,0 +1,81 @@
+package run
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prashantv/gostub" // spell: disable-line
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadFixture(t *testing.T) {
+	fixture, err := ReadFixture(filepath.Join("testdata", "fixture.yaml"))
+	assert.NoError(t, err)
+	assert.Len(t, fixture.Recordings, 4)
+	assert.Equal(t, []string{"git", "rev-parse", "--abbrev-ref", "HEAD"}, fixture.Recordings[0].Args)
+	assert.Equal(t, 4200*time.Microsecond, fixture.Recordings[0].Duration)
+
+	_, err = ReadFixture(filepath.Join("testdata", "nope.yaml"))
+	assert.Error(t, err)
+
+	stubs := gostub.StubFunc(&osReadFile, []byte("recordings: [[["), nil)
+	defer stubs.Reset()
+
+	_, err = ReadFixture(filepath.Join("testdata", "fixture.yaml"))
+	assert.ErrorContains(t, err, "yaml")
+}
+
+func TestWriteFixture(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.yaml")
+	fixture := &Fixture{
+		Recordings: []*Recording{
+			{Args: []string{"echo", "hi"}, Stdout: "hi\n", Duration: time.Second},
+		},
+	}
+	assert.NoError(t, WriteFixture(path, fixture))
+
+	actual, err := ReadFixture(path)
+	assert.NoError(t, err)
+	assert.Equal(t, fixture, actual)
+
+	stubs := gostub.StubFunc(&osWriteFile, errors.New("boom"))
+	defer stubs.Reset()
+	assert.ErrorContains(t, WriteFixture(path, fixture), "boom")
+}
+
+func TestNewReplayExecutor(t *testing.T) {
+	executor, err := NewReplayExecutor(filepath.Join("testdata", "fixture.yaml"))
+	assert.NoError(t, err)
+	client := &Client{Executor: executor}
+
+	buf, err := client.Command("git", "rev-parse", "--abbrev-ref", "HEAD").Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "main\n", string(buf))
+
+	// Stdin must match.
+	cmd := client.Command("git", "hash-object", "--stdin")
+	_, err = cmd.Output()
+	assert.ErrorContains(t, err, "no registered stubs matching")
+
+	cmd = client.Command("git", "hash-object", "--stdin")
+	cmd.Stdin = bytes.NewBufferString("hello")
+	buf, err = cmd.Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "b6fc4c620b67d95f953a5c1c1230aaab5db5a1b0\n", string(buf))
+
+	cmd = client.Command("git", "show", "nope")
+	_, err = cmd.Output()
+	assert.ErrorContains(t, err, "exit status 128")
+	assert.Contains(t, string(err.(*ExitError).Stderr), "unknown revision")
+
+	err = client.Command("kubectl", "version").Run()
+	assert.ErrorContains(t, err, "executable file not found")
+
+	executor.VerifyStubs(t)
+
+	_, err = NewReplayExecutor(filepath.Join("testdata", "nope.yaml"))
+	assert.Error(t, err)
+}
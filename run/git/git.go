@@ -0,0 +1,242 @@
+// Package git provides typed helpers for common git commands.
+//
+// All commands are run via [run.Client], so they can be stubbed
+// (see the canned stubs in stubs.go).
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/twelvelabs/termite/run"
+)
+
+const (
+	// logFormat is the `git log --format` used to parse commits.
+	// Fields are separated by the unit separator and records by the record separator.
+	logFormat = "--format=%H%x1f%h%x1f%an%x1f%ae%x1f%aI%x1f%s%x1f%b%x1e"
+	fieldSep  = "\x1f"
+	recordSep = "\x1e"
+)
+
+var (
+	// ErrNotRepository is returned when the working dir is not inside a git repo.
+	ErrNotRepository = errors.New("not a git repository")
+)
+
+// Remote is a git remote.
+type Remote struct {
+	Name     string
+	FetchURL string
+	PushURL  string
+}
+
+// Commit is a git commit.
+type Commit struct {
+	SHA         string
+	ShortSHA    string
+	AuthorName  string
+	AuthorEmail string
+	AuthorDate  time.Time
+	Subject     string
+	Body        string
+}
+
+// NewClient returns a new git client that runs commands using runner.
+// If runner is nil, a new [run.Client] is used.
+func NewClient(runner *run.Client) *Client {
+	if runner == nil {
+		runner = run.NewClient()
+	}
+	return &Client{
+		Runner: runner,
+	}
+}
+
+// Client runs git commands.
+type Client struct {
+	// Runner is used to run git commands.
+	Runner *run.Client
+	// Dir is the working dir for git commands.
+	// Defaults to the current dir.
+	Dir string
+}
+
+// Command returns a git command for args.
+func (c *Client) Command(args ...string) *run.Cmd {
+	cmd := c.Runner.Command("git", args...)
+	cmd.Dir = c.Dir
+	return cmd
+}
+
+// CurrentBranch returns the name of the current branch.
+// Returns "HEAD" when in a detached HEAD state.
+func (c *Client) CurrentBranch() (string, error) {
+	return c.outputString("rev-parse", "--abbrev-ref", "HEAD")
+}
+
+// RepoRoot returns the absolute path to the root of the repo.
+func (c *Client) RepoRoot() (string, error) {
+	return c.outputString("rev-parse", "--show-toplevel")
+}
+
+// Remotes returns the configured remotes, sorted by name.
+func (c *Client) Remotes() ([]Remote, error) {
+	lines, err := c.outputLines("remote", "-v")
+	if err != nil {
+		return nil, err
+	}
+
+	remotes := []Remote{}
+	index := map[string]int{}
+	for _, line := range lines {
+		// origin	git@github.com:org/repo.git (fetch)
+		// The URL may contain spaces (i.e. a local path), but the name can't.
+		name, rest, ok := strings.Cut(line, "\t")
+		sep := strings.LastIndex(rest, " ")
+		if !ok || sep < 0 {
+			return nil, fmt.Errorf("unable to parse remote: %q", line)
+		}
+		url, kind := rest[:sep], rest[sep+1:]
+		i, ok := index[name]
+		if !ok {
+			i = len(remotes)
+			index[name] = i
+			remotes = append(remotes, Remote{Name: name})
+		}
+		switch kind {
+		case "(fetch)":
+			remotes[i].FetchURL = url
+		case "(push)":
+			remotes[i].PushURL = url
+		}
+	}
+	return remotes, nil
+}
+
+// Remote returns the named remote.
+func (c *Client) Remote(name string) (Remote, error) {
+	remotes, err := c.Remotes()
+	if err != nil {
+		return Remote{}, err
+	}
+	for _, r := range remotes {
+		if r.Name == name {
+			return r, nil
+		}
+	}
+	return Remote{}, fmt.Errorf("no such remote: %s", name)
+}
+
+// IsDirty returns true if the working tree has uncommitted changes
+// (including untracked files).
+func (c *Client) IsDirty() (bool, error) {
+	entries, err := c.outputEntries("status", "--porcelain", "-z")
+	if err != nil {
+		return false, err
+	}
+	return len(entries) > 0, nil
+}
+
+// Tags returns the tags in the repo.
+func (c *Client) Tags() ([]string, error) {
+	return c.outputLines("tag", "--list")
+}
+
+// Log returns the commits reachable from HEAD, newest first.
+// Any args are appended to the `git log` command
+// (i.e. "-n", "10", or "main..feature").
+func (c *Client) Log(args ...string) ([]Commit, error) {
+	buf, err := c.output(append([]string{"log", logFormat}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	return parseLog(string(buf))
+}
+
+// DiffNames returns the names of the files that differ.
+// Any args are appended to the `git diff --name-only -z` command
+// (i.e. "main...HEAD" or "--cached").
+func (c *Client) DiffNames(args ...string) ([]string, error) {
+	return c.outputEntries(append([]string{"diff", "--name-only", "-z"}, args...)...)
+}
+
+func (c *Client) output(args ...string) ([]byte, error) {
+	buf, err := c.Command(args...).Output()
+	if err != nil {
+		var exitErr *run.ExitError
+		if errors.As(err, &exitErr) && strings.Contains(exitErr.StderrTail, "not a git repository") {
+			return nil, fmt.Errorf("%w: %w", ErrNotRepository, err)
+		}
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (c *Client) outputString(args ...string) (string, error) {
+	buf, err := c.output(args...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(buf)), nil
+}
+
+func (c *Client) outputLines(args ...string) ([]string, error) {
+	buf, err := c.output(args...)
+	if err != nil {
+		return nil, err
+	}
+	lines := []string{}
+	for _, line := range strings.Split(string(buf), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// outputEntries returns the NUL separated entries output by a command run
+// with `-z`. Unlike outputLines, file names are returned verbatim.
+func (c *Client) outputEntries(args ...string) ([]string, error) {
+	buf, err := c.output(args...)
+	if err != nil {
+		return nil, err
+	}
+	entries := []string{}
+	for _, entry := range strings.Split(string(buf), "\x00") {
+		if entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func parseLog(output string) ([]Commit, error) {
+	commits := []Commit{}
+	for _, record := range strings.Split(output, recordSep) {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
+		}
+		fields := strings.Split(record, fieldSep)
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("unable to parse commit: %q", record)
+		}
+		date, err := time.Parse(time.RFC3339, fields[4])
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse commit date: %w", err)
+		}
+		commits = append(commits, Commit{
+			SHA:         fields[0],
+			ShortSHA:    fields[1],
+			AuthorName:  fields[2],
+			AuthorEmail: fields[3],
+			AuthorDate:  date,
+			Subject:     fields[5],
+			Body:        strings.TrimSpace(fields[6]),
+		})
+	}
+	return commits, nil
+}
@@ -0,0 +1,251 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twelvelabs/termite/run"
+)
+
+func TestNewClient(t *testing.T) {
+	client := NewClient(nil)
+	assert.NotNil(t, client.Runner)
+	assert.Equal(t, run.DefaultExecutor, client.Runner.Executor)
+
+	runner := run.NewClient()
+	client = NewClient(runner)
+	assert.Equal(t, runner, client.Runner)
+}
+
+func TestClient_Command(t *testing.T) {
+	client := NewClient(nil)
+	client.Dir = "/src/app"
+	cmd := client.Command("status")
+	assert.Equal(t, []string{"git", "status"}, cmd.Args)
+	assert.Equal(t, "/src/app", cmd.Dir)
+}
+
+func TestClient_CurrentBranch(t *testing.T) {
+	runner := run.NewClient().WithStubbing()
+	defer runner.VerifyStubs(t)
+	runner.RegisterStub(StubCurrentBranch("main"))
+
+	branch, err := NewClient(runner).CurrentBranch()
+	assert.NoError(t, err)
+	assert.Equal(t, "main", branch)
+}
+
+func TestClient_RepoRoot(t *testing.T) {
+	runner := run.NewClient().WithStubbing()
+	defer runner.VerifyStubs(t)
+	runner.RegisterStub(StubRepoRoot("/src/app"))
+
+	root, err := NewClient(runner).RepoRoot()
+	assert.NoError(t, err)
+	assert.Equal(t, "/src/app", root)
+}
+
+func TestClient_Remotes(t *testing.T) {
+	runner := run.NewClient().WithStubbing()
+	defer runner.VerifyStubs(t)
+	runner.RegisterStub(StubRemotes(
+		Remote{Name: "origin", FetchURL: "git@github.com:org/repo.git"},
+		Remote{Name: "upstream", FetchURL: "https://example.com/a.git", PushURL: "no_push"},
+		Remote{Name: "local", FetchURL: "/src/my repos/a.git"},
+	))
+	runner.RegisterStub(StubRemotes())
+	runner.RegisterStub(run.MatchArgv("git", "remote", "-v"), run.StringResponse("nope\n"))
+
+	client := NewClient(runner)
+	remotes, err := client.Remotes()
+	assert.NoError(t, err)
+	assert.Equal(t, []Remote{
+		{
+			Name:     "origin",
+			FetchURL: "git@github.com:org/repo.git",
+			PushURL:  "git@github.com:org/repo.git",
+		},
+		{
+			Name:     "upstream",
+			FetchURL: "https://example.com/a.git",
+			PushURL:  "no_push",
+		},
+		{
+			Name:     "local",
+			FetchURL: "/src/my repos/a.git",
+			PushURL:  "/src/my repos/a.git",
+		},
+	}, remotes)
+
+	_, err = client.Remote("origin")
+	assert.ErrorContains(t, err, "no such remote: origin")
+
+	_, err = client.Remotes()
+	assert.ErrorContains(t, err, `unable to parse remote: "nope"`)
+}
+
+func TestClient_IsDirty(t *testing.T) {
+	runner := run.NewClient().WithStubbing()
+	defer runner.VerifyStubs(t)
+	runner.RegisterStub(StubStatus())
+	runner.RegisterStub(StubStatus("main.go"))
+
+	client := NewClient(runner)
+	dirty, err := client.IsDirty()
+	assert.NoError(t, err)
+	assert.False(t, dirty)
+
+	dirty, err = client.IsDirty()
+	assert.NoError(t, err)
+	assert.True(t, dirty)
+}
+
+func TestClient_Tags(t *testing.T) {
+	runner := run.NewClient().WithStubbing()
+	defer runner.VerifyStubs(t)
+	runner.RegisterStub(StubTags("v0.1.0", "v0.2.0"))
+
+	tags, err := NewClient(runner).Tags()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"v0.1.0", "v0.2.0"}, tags)
+}
+
+func TestClient_Log(t *testing.T) {
+	date := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	commits := []Commit{
+		{
+			SHA:         "5d1b9f6a4c2e8b7d3f0a1c9e8b7d6f5a4c3b2a10",
+			ShortSHA:    "5d1b9f6",
+			AuthorName:  "Some Person",
+			AuthorEmail: "person@example.com",
+			AuthorDate:  date,
+			Subject:     "Add the thing",
+			Body:        "Details\n\nMore details",
+		},
+		{
+			SHA:         "0a1b2c3d4e5f60718293a4b5c6d7e8f901234567",
+			ShortSHA:    "0a1b2c3",
+			AuthorName:  "Other Person",
+			AuthorEmail: "other@example.com",
+			AuthorDate:  date.Add(-time.Hour),
+			Subject:     "Initial commit",
+		},
+	}
+	runner := run.NewClient().WithStubbing()
+	defer runner.VerifyStubs(t)
+	runner.RegisterStub(StubLog(commits...))
+	runner.RegisterStub(run.MatchArgvPrefix("git", "log"), run.StringResponse("nope\x1e"))
+
+	client := NewClient(runner)
+	actual, err := client.Log("-n", "2")
+	assert.NoError(t, err)
+	assert.Equal(t, commits, actual)
+
+	_, err = client.Log()
+	assert.ErrorContains(t, err, "unable to parse commit")
+}
+
+func TestClient_DiffNames(t *testing.T) {
+	runner := run.NewClient().WithStubbing()
+	defer runner.VerifyStubs(t)
+	runner.RegisterStub(StubDiffNames("a.go", " b/c d.go", "e\nf.go"))
+
+	names, err := NewClient(runner).DiffNames("main...HEAD")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.go", " b/c d.go", "e\nf.go"}, names)
+}
+
+func TestClient_WhenNotRepository(t *testing.T) {
+	runner := run.NewClient().WithStubbing()
+	defer runner.VerifyStubs(t)
+	runner.RegisterStub(StubNotRepository())
+	runner.RegisterStub(run.MatchAny, run.ErrorResponse(run.NewExitError(1)))
+
+	client := NewClient(runner)
+	_, err := client.CurrentBranch()
+	assert.ErrorIs(t, err, ErrNotRepository)
+	var exitErr *run.ExitError
+	assert.ErrorAs(t, err, &exitErr)
+
+	_, err = client.Tags()
+	assert.NotErrorIs(t, err, ErrNotRepository)
+	assert.ErrorContains(t, err, "git tag --list: exit status 1")
+}
+
+func TestClient_Integration(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	dir := t.TempDir()
+	dir, _ = filepath.EvalSymlinks(dir)
+	client := NewClient(nil)
+	client.Dir = dir
+
+	_, err := client.RepoRoot()
+	assert.ErrorIs(t, err, ErrNotRepository)
+
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"config", "user.name", "Some Person"},
+		{"config", "user.email", "person@example.com"},
+		{"remote", "add", "origin", "https://example.com/repo.git"},
+	} {
+		assert.NoError(t, client.Command(args...).Run())
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0600))
+
+	dirty, err := client.IsDirty()
+	assert.NoError(t, err)
+	assert.True(t, dirty)
+
+	assert.NoError(t, client.Command("add", ".").Run())
+	assert.NoError(t, client.Command("commit", "-q", "-m", "First", "-m", "Body").Run())
+	assert.NoError(t, client.Command("tag", "v1.0.0").Run())
+
+	root, err := client.RepoRoot()
+	assert.NoError(t, err)
+	assert.Equal(t, dir, root)
+
+	branch, err := client.CurrentBranch()
+	assert.NoError(t, err)
+	assert.Equal(t, "main", branch)
+
+	dirty, err = client.IsDirty()
+	assert.NoError(t, err)
+	assert.False(t, dirty)
+
+	remote, err := client.Remote("origin")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/repo.git", remote.PushURL)
+
+	tags, err := client.Tags()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"v1.0.0"}, tags)
+
+	commits, err := client.Log()
+	assert.NoError(t, err)
+	assert.Len(t, commits, 1)
+	assert.Equal(t, "First", commits[0].Subject)
+	assert.Equal(t, "Body", commits[0].Body)
+	assert.Equal(t, "person@example.com", commits[0].AuthorEmail)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("b"), 0600))
+	names, err := client.DiffNames()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.txt"}, names)
+
+	// Names aren't quoted or trimmed.
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, " b é.txt"), []byte("b"), 0600))
+	assert.NoError(t, client.Command("add", ".").Run())
+	names, err = client.DiffNames("--cached")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{" b é.txt", "a.txt"}, names)
+}
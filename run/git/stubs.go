@@ -0,0 +1,101 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/twelvelabs/termite/run"
+)
+
+// Canned stubs for the commands run by [Client].
+// Each returns a matcher/responder pair for use with RegisterStub:
+//
+//	client := run.NewClient().WithStubbing()
+//	client.RegisterStub(git.StubCurrentBranch("main"))
+//	branch, _ := git.NewClient(client).CurrentBranch() // "main"
+
+// StubCurrentBranch stubs [Client.CurrentBranch].
+func StubCurrentBranch(branch string) (run.Matcher, run.Responder) {
+	return run.MatchArgv("git", "rev-parse", "--abbrev-ref", "HEAD"),
+		run.StringResponse(branch + "\n")
+}
+
+// StubRepoRoot stubs [Client.RepoRoot].
+func StubRepoRoot(path string) (run.Matcher, run.Responder) {
+	return run.MatchArgv("git", "rev-parse", "--show-toplevel"),
+		run.StringResponse(path + "\n")
+}
+
+// StubRemotes stubs [Client.Remotes].
+func StubRemotes(remotes ...Remote) (run.Matcher, run.Responder) {
+	lines := []string{}
+	for _, r := range remotes {
+		push := r.PushURL
+		if push == "" {
+			push = r.FetchURL
+		}
+		lines = append(lines,
+			fmt.Sprintf("%s\t%s (fetch)", r.Name, r.FetchURL),
+			fmt.Sprintf("%s\t%s (push)", r.Name, push),
+		)
+	}
+	return run.MatchArgv("git", "remote", "-v"),
+		run.LinesResponse(lines...)
+}
+
+// StubStatus stubs [Client.IsDirty].
+// Each path is reported as modified; no paths means a clean working tree.
+func StubStatus(paths ...string) (run.Matcher, run.Responder) {
+	sb := strings.Builder{}
+	for _, p := range paths {
+		sb.WriteString(" M " + p + "\x00")
+	}
+	return run.MatchArgv("git", "status", "--porcelain", "-z"),
+		run.StringResponse(sb.String())
+}
+
+// StubTags stubs [Client.Tags].
+func StubTags(tags ...string) (run.Matcher, run.Responder) {
+	return run.MatchArgv("git", "tag", "--list"),
+		run.LinesResponse(tags...)
+}
+
+// StubLog stubs [Client.Log] (regardless of any extra args).
+func StubLog(commits ...Commit) (run.Matcher, run.Responder) {
+	sb := strings.Builder{}
+	for _, c := range commits {
+		sb.WriteString(strings.Join([]string{
+			c.SHA,
+			c.ShortSHA,
+			c.AuthorName,
+			c.AuthorEmail,
+			c.AuthorDate.Format(time.RFC3339),
+			c.Subject,
+			c.Body,
+		}, fieldSep))
+		sb.WriteString(recordSep + "\n")
+	}
+	return run.MatchArgvPrefix("git", "log", logFormat),
+		run.StringResponse(sb.String())
+}
+
+// StubDiffNames stubs [Client.DiffNames] (regardless of any extra args).
+func StubDiffNames(names ...string) (run.Matcher, run.Responder) {
+	sb := strings.Builder{}
+	for _, name := range names {
+		sb.WriteString(name + "\x00")
+	}
+	return run.MatchArgvPrefix("git", "diff", "--name-only", "-z"),
+		run.StringResponse(sb.String())
+}
+
+// StubNotRepository stubs any git command failing because the
+// working dir is not a git repo.
+func StubNotRepository() (run.Matcher, run.Responder) {
+	return run.MatchArgvPrefix("git"),
+		func(cmd *run.Cmd) ([]byte, []byte, error) {
+			stderr := "fatal: not a git repository (or any of the parent directories): .git\n"
+			return nil, []byte(stderr), run.NewExitError(128)
+		}
+}
@@ -1,7 +1,11 @@
 package run
 
 import (
+	"encoding/json"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"strings"
 )
 
 // Matcher is function that matches commands.
@@ -24,6 +28,121 @@ func MatchAll(matchers ...Matcher) Matcher {
 	}
 }
 
+// MatchAnyOf returns a matcher that returns true if any of the matcher args match.
+func MatchAnyOf(matchers ...Matcher) Matcher {
+	return func(cmd *Cmd) bool {
+		for _, matcher := range matchers {
+			if ok := matcher(cmd); ok {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not returns a matcher that negates matcher.
+func Not(matcher Matcher) Matcher {
+	return func(cmd *Cmd) bool {
+		return !matcher(cmd)
+	}
+}
+
+// MatchName returns a matcher that matches against the program name
+// (the base name of the first arg).
+//
+// For example, `MatchName("git")` matches both `git status` and `/usr/bin/git status`.
+func MatchName(name string) Matcher {
+	return func(cmd *Cmd) bool {
+		args := cmdArgs(cmd)
+		return len(args) > 0 && filepath.Base(args[0]) == name
+	}
+}
+
+// MatchArgv returns a matcher that matches the exact command args
+// (including the program name).
+func MatchArgv(argv ...string) Matcher {
+	return func(cmd *Cmd) bool {
+		return slices.Equal(argv, cmdArgs(cmd))
+	}
+}
+
+// MatchArgvPrefix returns a matcher that matches commands whose args
+// begin with prefix.
+//
+// For example, `MatchArgvPrefix("git", "rev-parse")` matches `git rev-parse HEAD`.
+func MatchArgvPrefix(prefix ...string) Matcher {
+	return func(cmd *Cmd) bool {
+		args := cmdArgs(cmd)
+		if len(prefix) > len(args) {
+			return false
+		}
+		return slices.Equal(prefix, args[:len(prefix)])
+	}
+}
+
+// MatchArgvGlob returns a matcher that matches command args against
+// shell style glob patterns, one pattern per arg.
+// Within a pattern, `*` matches any sequence of characters and `?`
+// matches a single character. A pattern of `**` matches zero or more args.
+//
+// For example, `MatchArgvGlob("git", "commit", "**", "-m", "fix*")`.
+func MatchArgvGlob(patterns ...string) Matcher {
+	regexps := make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		if p != "**" {
+			regexps[i] = globToRegexp(p)
+		}
+	}
+	return func(cmd *Cmd) bool {
+		return matchGlobs(regexps, cmdArgs(cmd))
+	}
+}
+
+// MatchArgvSubsequence returns a matcher that matches commands containing
+// all of args, in order, though not necessarily adjacent.
+//
+// For example, `MatchArgvSubsequence("kubectl", "-o", "json")` matches
+// `kubectl get pods -n default -o json`.
+func MatchArgvSubsequence(args ...string) Matcher {
+	return func(cmd *Cmd) bool {
+		i := 0
+		for _, arg := range cmdArgs(cmd) {
+			if i < len(args) && arg == args[i] {
+				i++
+			}
+		}
+		return i == len(args)
+	}
+}
+
+// MatchDir returns a matcher that matches against the command working dir.
+func MatchDir(dir string) Matcher {
+	return func(cmd *Cmd) bool {
+		if cmd.Cmd == nil {
+			return false
+		}
+		return filepath.Clean(dir) == filepath.Clean(cmd.Dir)
+	}
+}
+
+// MatchEnv returns a matcher that matches commands whose effective
+// environment contains key set to value.
+func MatchEnv(key string, value string) Matcher {
+	return func(cmd *Cmd) bool {
+		v, ok := cmdEnv(cmd)[key]
+		return ok && v == value
+	}
+}
+
+// MatchEnvPresent returns a matcher that matches commands whose effective
+// environment contains key (regardless of value).
+func MatchEnvPresent(key string) Matcher {
+	return func(cmd *Cmd) bool {
+		_, ok := cmdEnv(cmd)[key]
+		return ok
+	}
+}
+
 // MatchStdin returns a matcher that matches against command stdin.
 func MatchStdin(s string) Matcher {
 	return func(cmd *Cmd) bool {
@@ -32,6 +151,38 @@ func MatchStdin(s string) Matcher {
 	}
 }
 
+// MatchStdinRegexp returns a matcher that matches command stdin
+// against a regular expression.
+func MatchStdinRegexp(s string) Matcher {
+	r := regexp.MustCompile(s)
+	return func(cmd *Cmd) bool {
+		data, _ := cmd.PeekStdin()
+		return r.Match(data)
+	}
+}
+
+// MatchStdinJSON returns a matcher that matches commands whose stdin
+// is JSON with the same shape as v. Only the keys present in v are compared,
+// so extra keys in stdin are ignored. Panics if v can not be marshalled.
+func MatchStdinJSON(v any) Matcher {
+	var expected any
+	data, err := json.Marshal(v)
+	if err == nil {
+		err = json.Unmarshal(data, &expected)
+	}
+	if err != nil {
+		panic(err)
+	}
+	return func(cmd *Cmd) bool {
+		data, _ := cmd.PeekStdin()
+		var actual any
+		if err := json.Unmarshal(data, &actual); err != nil {
+			return false
+		}
+		return jsonContains(actual, expected)
+	}
+}
+
 // MatchString returns a matcher that matches against command strings.
 func MatchString(s string) Matcher {
 	return func(cmd *Cmd) bool {
@@ -46,3 +197,86 @@ func MatchRegexp(s string) Matcher {
 		return r.MatchString(cmd.String())
 	}
 }
+
+func cmdArgs(cmd *Cmd) []string {
+	if cmd.Cmd == nil {
+		return nil
+	}
+	return cmd.Args
+}
+
+func cmdEnv(cmd *Cmd) map[string]string {
+	if cmd.Cmd == nil {
+		return nil
+	}
+	return envMap(cmd.Environ())
+}
+
+func globToRegexp(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
+
+// matchGlobs matches args against patterns, where a nil pattern is `**`.
+func matchGlobs(patterns []*regexp.Regexp, args []string) bool {
+	if len(patterns) == 0 {
+		return len(args) == 0
+	}
+	if patterns[0] == nil {
+		for i := 0; i <= len(args); i++ {
+			if matchGlobs(patterns[1:], args[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(args) == 0 || !patterns[0].MatchString(args[0]) {
+		return false
+	}
+	return matchGlobs(patterns[1:], args[1:])
+}
+
+// jsonContains returns true if every value in expected is present in actual.
+// Objects are compared by subset, arrays element-wise, and scalars by equality.
+func jsonContains(actual any, expected any) bool {
+	switch e := expected.(type) {
+	case map[string]any:
+		a, ok := actual.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, ev := range e {
+			av, ok := a[k]
+			if !ok || !jsonContains(av, ev) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		a, ok := actual.([]any)
+		if !ok || len(a) != len(e) {
+			return false
+		}
+		for i := range e {
+			if !jsonContains(a[i], e[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		// Scalars (expected is never a map or slice here, so this can't panic).
+		return actual == expected
+	}
+}
@@ -50,6 +50,293 @@ func TestMatcher(t *testing.T) {
 			matches: false,
 		},
 
+		{
+			desc: "MatchAnyOf: match",
+			matcher: MatchAnyOf(
+				MatchString("/bin/true"),
+				MatchString("/bin/echo"),
+			),
+			cmd:     client.Command("/bin/echo"),
+			matches: true,
+		},
+		{
+			desc: "MatchAnyOf: no match",
+			matcher: MatchAnyOf(
+				MatchString("/bin/true"),
+				MatchString("/bin/false"),
+			),
+			cmd:     client.Command("/bin/echo"),
+			matches: false,
+		},
+
+		{
+			desc:    "Not: match",
+			matcher: Not(MatchString("/bin/true")),
+			cmd:     client.Command("/bin/echo"),
+			matches: true,
+		},
+		{
+			desc:    "Not: no match",
+			matcher: Not(MatchString("/bin/echo")),
+			cmd:     client.Command("/bin/echo"),
+			matches: false,
+		},
+
+		{
+			desc:    "MatchName: match",
+			matcher: MatchName("echo"),
+			cmd:     client.Command("/bin/echo", "foo"),
+			matches: true,
+		},
+		{
+			desc:    "MatchName: no match",
+			matcher: MatchName("echo"),
+			cmd:     client.Command("/bin/cat", "echo"),
+			matches: false,
+		},
+		{
+			desc:    "MatchName: empty cmd",
+			matcher: MatchName("echo"),
+			cmd:     &Cmd{},
+			matches: false,
+		},
+
+		{
+			desc:    "MatchArgv: match",
+			matcher: MatchArgv("git", "commit", "-m", "some message"),
+			cmd:     client.Command("git", "commit", "-m", "some message"),
+			matches: true,
+		},
+		{
+			desc:    "MatchArgv: no match",
+			matcher: MatchArgv("git", "commit", "-m", "some"),
+			cmd:     client.Command("git", "commit", "-m", "some message"),
+			matches: false,
+		},
+
+		{
+			desc:    "MatchArgvPrefix: match",
+			matcher: MatchArgvPrefix("git", "rev-parse"),
+			cmd:     client.Command("git", "rev-parse", "HEAD"),
+			matches: true,
+		},
+		{
+			desc:    "MatchArgvPrefix: no match",
+			matcher: MatchArgvPrefix("git", "rev-parse"),
+			cmd:     client.Command("git", "status"),
+			matches: false,
+		},
+		{
+			desc:    "MatchArgvPrefix: prefix too long",
+			matcher: MatchArgvPrefix("git", "rev-parse", "HEAD"),
+			cmd:     client.Command("git", "rev-parse"),
+			matches: false,
+		},
+		{
+			desc:    "MatchArgvPrefix: empty prefix",
+			matcher: MatchArgvPrefix(),
+			cmd:     client.Command("git", "status"),
+			matches: true,
+		},
+
+		{
+			desc:    "MatchArgvGlob: match",
+			matcher: MatchArgvGlob("*git", "commit", "**", "-m", "fix?*"),
+			cmd:     client.Command("/usr/bin/git", "commit", "--amend", "-q", "-m", "fix: things"),
+			matches: true,
+		},
+		{
+			desc:    "MatchArgvGlob: match when ** is empty",
+			matcher: MatchArgvGlob("git", "**", "status", "**"),
+			cmd:     client.Command("git", "status"),
+			matches: true,
+		},
+		{
+			desc:    "MatchArgvGlob: no match",
+			matcher: MatchArgvGlob("git", "commit", "-m", "fix*"),
+			cmd:     client.Command("git", "commit", "-m", "feat: things"),
+			matches: false,
+		},
+		{
+			desc:    "MatchArgvGlob: too few args",
+			matcher: MatchArgvGlob("git", "commit", "*"),
+			cmd:     client.Command("git", "commit"),
+			matches: false,
+		},
+		{
+			desc:    "MatchArgvGlob: too many args",
+			matcher: MatchArgvGlob("git", "commit"),
+			cmd:     client.Command("git", "commit", "-a"),
+			matches: false,
+		},
+		{
+			desc:    "MatchArgvGlob: regexp chars are literal",
+			matcher: MatchArgvGlob("echo", "a.c"),
+			cmd:     client.Command("echo", "abc"),
+			matches: false,
+		},
+
+		{
+			desc:    "MatchArgvSubsequence: match",
+			matcher: MatchArgvSubsequence("kubectl", "-o", "json"),
+			cmd:     client.Command("kubectl", "get", "pods", "-o", "json"),
+			matches: true,
+		},
+		{
+			desc:    "MatchArgvSubsequence: out of order",
+			matcher: MatchArgvSubsequence("kubectl", "json", "-o"),
+			cmd:     client.Command("kubectl", "get", "pods", "-o", "json"),
+			matches: false,
+		},
+
+		{
+			desc:    "MatchDir: match",
+			matcher: MatchDir("/tmp/foo/"),
+			cmd: func() *Cmd {
+				cmd := client.Command("ls")
+				cmd.Dir = "/tmp/foo"
+				return cmd
+			}(),
+			matches: true,
+		},
+		{
+			desc:    "MatchDir: no match",
+			matcher: MatchDir("/tmp/foo"),
+			cmd:     client.Command("ls"),
+			matches: false,
+		},
+		{
+			desc:    "MatchDir: empty cmd",
+			matcher: MatchDir("/tmp/foo"),
+			cmd:     &Cmd{},
+			matches: false,
+		},
+
+		{
+			desc:    "MatchEnv: match",
+			matcher: MatchEnv("FOO", "bar"),
+			cmd: func() *Cmd {
+				cmd := client.Command("env")
+				cmd.Env = []string{"FOO=baz", "FOO=bar"}
+				return cmd
+			}(),
+			matches: true,
+		},
+		{
+			desc:    "MatchEnv: no match",
+			matcher: MatchEnv("FOO", "bar"),
+			cmd: func() *Cmd {
+				cmd := client.Command("env")
+				cmd.Env = []string{"FOO=baz"}
+				return cmd
+			}(),
+			matches: false,
+		},
+		{
+			desc:    "MatchEnvPresent: match",
+			matcher: MatchEnvPresent("FOO"),
+			cmd: func() *Cmd {
+				cmd := client.Command("env")
+				cmd.Env = []string{"FOO="}
+				return cmd
+			}(),
+			matches: true,
+		},
+		{
+			desc:    "MatchEnvPresent: no match",
+			matcher: MatchEnvPresent("FOO"),
+			cmd: func() *Cmd {
+				cmd := client.Command("env")
+				cmd.Env = []string{"BAR=1"}
+				return cmd
+			}(),
+			matches: false,
+		},
+		{
+			desc:    "MatchEnvPresent: empty cmd",
+			matcher: MatchEnvPresent("FOO"),
+			cmd:     &Cmd{},
+			matches: false,
+		},
+
+		{
+			desc:    "MatchStdinRegexp: match",
+			matcher: MatchStdinRegexp(`^how\w+$`),
+			cmd: func() *Cmd {
+				cmd := client.Command("/bin/cat")
+				cmd.Stdin = bytes.NewBufferString("howdy")
+				return cmd
+			}(),
+			matches: true,
+		},
+		{
+			desc:    "MatchStdinRegexp: no match",
+			matcher: MatchStdinRegexp(`^how\w+$`),
+			cmd: func() *Cmd {
+				cmd := client.Command("/bin/cat")
+				cmd.Stdin = bytes.NewBufferString("hello")
+				return cmd
+			}(),
+			matches: false,
+		},
+
+		{
+			desc: "MatchStdinJSON: match",
+			matcher: MatchStdinJSON(map[string]any{
+				"name": "foo",
+				"tags": []string{"a", "b"},
+				"meta": map[string]any{"count": 2},
+			}),
+			cmd: func() *Cmd {
+				cmd := client.Command("/bin/cat")
+				cmd.Stdin = bytes.NewBufferString(
+					`{"name": "foo", "extra": true, "tags": ["a", "b"], "meta": {"count": 2, "x": 1}}`,
+				)
+				return cmd
+			}(),
+			matches: true,
+		},
+		{
+			desc:    "MatchStdinJSON: no match",
+			matcher: MatchStdinJSON(map[string]any{"tags": []string{"a", "b"}}),
+			cmd: func() *Cmd {
+				cmd := client.Command("/bin/cat")
+				cmd.Stdin = bytes.NewBufferString(`{"tags": ["a"]}`)
+				return cmd
+			}(),
+			matches: false,
+		},
+		{
+			desc:    "MatchStdinJSON: type mismatch",
+			matcher: MatchStdinJSON(map[string]any{"meta": map[string]any{"count": 2}}),
+			cmd: func() *Cmd {
+				cmd := client.Command("/bin/cat")
+				cmd.Stdin = bytes.NewBufferString(`{"meta": [2]}`)
+				return cmd
+			}(),
+			matches: false,
+		},
+		{
+			desc:    "MatchStdinJSON: array type mismatch",
+			matcher: MatchStdinJSON([]int{1}),
+			cmd: func() *Cmd {
+				cmd := client.Command("/bin/cat")
+				cmd.Stdin = bytes.NewBufferString(`{"0": 1}`)
+				return cmd
+			}(),
+			matches: false,
+		},
+		{
+			desc:    "MatchStdinJSON: invalid JSON",
+			matcher: MatchStdinJSON(map[string]any{"name": "foo"}),
+			cmd: func() *Cmd {
+				cmd := client.Command("/bin/cat")
+				cmd.Stdin = bytes.NewBufferString(`name: foo`)
+				return cmd
+			}(),
+			matches: false,
+		},
+
 		{
 			desc:    "MatchStdin: match",
 			matcher: MatchStdin("howdy"),
@@ -124,3 +411,9 @@ func TestMatchStdin_ShouldRewindReader(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "howdy", string(data))
 }
+
+func TestMatchStdinJSON_WhenInvalidValue(t *testing.T) {
+	assert.Panics(t, func() {
+		MatchStdinJSON(make(chan int))
+	})
+}
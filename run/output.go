@@ -0,0 +1,109 @@
+package run
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+const (
+	// maxSnippetLen is the max amount of output included in a DecodeError.
+	maxSnippetLen = 100
+)
+
+// DecodeError is returned when command output can not be decoded.
+type DecodeError struct {
+	// Cmd is the debug string for the command (see [Cmd.DebugString]).
+	Cmd string
+	// Format is the format that was being decoded (i.e. "json").
+	Format string
+	// Snippet is a truncated preview of the command output.
+	Snippet string
+	// Err is the underlying decode error.
+	Err error
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Error returns the error message.
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("unable to decode %s output of %s: %v [Output: %q]", e.Format, e.Cmd, e.Err, e.Snippet)
+}
+
+// OutputJSON runs the command and decodes its standard output as JSON.
+//
+// For example:
+//
+//	type Pod struct { ... }
+//	pods, err := run.OutputJSON[[]Pod](client.Command("kubectl", "get", "pods", "-o", "json"))
+func OutputJSON[T any](cmd *Cmd) (T, error) {
+	var v T
+	data, err := cmd.Output()
+	if err != nil {
+		return v, err
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, newDecodeError(cmd, "json", data, err)
+	}
+	return v, nil
+}
+
+// OutputYAML runs the command and decodes its standard output as YAML.
+func OutputYAML[T any](cmd *Cmd) (T, error) {
+	var v T
+	data, err := cmd.Output()
+	if err != nil {
+		return v, err
+	}
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return v, newDecodeError(cmd, "yaml", data, err)
+	}
+	return v, nil
+}
+
+// OutputLines runs the command and returns its standard output split into lines.
+// Line endings (including "\r\n") are stripped, as is a trailing empty line.
+func (c *Cmd) OutputLines() ([]string, error) {
+	data, err := c.Output()
+	if err != nil {
+		return nil, err
+	}
+	s := strings.TrimSuffix(string(data), "\n")
+	if s == "" {
+		return []string{}, nil
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines, nil
+}
+
+// OutputCSV runs the command and decodes its standard output as CSV records.
+func (c *Cmd) OutputCSV() ([][]string, error) {
+	data, err := c.Output()
+	if err != nil {
+		return nil, err
+	}
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, newDecodeError(c, "csv", data, err)
+	}
+	return records, nil
+}
+
+func newDecodeError(cmd *Cmd, format string, data []byte, err error) *DecodeError {
+	snippet := truncate(cmd.Redact(string(data)), maxSnippetLen)
+	return &DecodeError{
+		Cmd:     cmd.DebugString(),
+		Format:  format,
+		Snippet: snippet,
+		Err:     err,
+	}
+}
@@ -0,0 +1,120 @@
+package run
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type outputWidget struct {
+	Name  string   `json:"name" yaml:"name"`
+	Count int      `json:"count" yaml:"count"`
+	Tags  []string `json:"tags" yaml:"tags"`
+}
+
+func TestOutputJSON(t *testing.T) {
+	client := NewClient().WithStubbing()
+	defer client.VerifyStubs(t)
+
+	widget := outputWidget{Name: "foo", Count: 2, Tags: []string{"a"}}
+	client.RegisterStub(MatchArgv("widget", "get"), JSONResponse(widget))
+	client.RegisterStub(MatchArgv("widget", "get"), StringResponse(`{"name": 1}`))
+	client.RegisterStub(MatchArgv("widget", "get"), ErrorResponse(NewExitError(1)))
+
+	actual, err := OutputJSON[outputWidget](client.Command("widget", "get"))
+	assert.NoError(t, err)
+	assert.Equal(t, widget, actual)
+
+	_, err = OutputJSON[outputWidget](client.Command("widget", "get"))
+	var decodeErr *DecodeError
+	assert.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, "json", decodeErr.Format)
+	assert.Equal(t, "widget get", decodeErr.Cmd)
+	assert.Equal(t, `{"name": 1}`, decodeErr.Snippet)
+	assert.ErrorContains(t, err, `unable to decode json output of widget get: json: cannot unmarshal number`)
+
+	_, err = OutputJSON[outputWidget](client.Command("widget", "get"))
+	assert.ErrorContains(t, err, "exit status 1")
+}
+
+func TestOutputYAML(t *testing.T) {
+	client := NewClient().WithStubbing()
+	defer client.VerifyStubs(t)
+
+	widget := outputWidget{Name: "foo", Count: 2, Tags: []string{"a"}}
+	client.RegisterStub(MatchArgv("widget", "get"), YAMLResponse(widget))
+	client.RegisterStub(MatchArgv("widget", "get"), StringResponse("name: [[["))
+	client.RegisterStub(MatchArgv("widget", "get"), ErrorResponse(NewExitError(1)))
+
+	actual, err := OutputYAML[outputWidget](client.Command("widget", "get"))
+	assert.NoError(t, err)
+	assert.Equal(t, widget, actual)
+
+	_, err = OutputYAML[outputWidget](client.Command("widget", "get"))
+	var decodeErr *DecodeError
+	assert.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, "yaml", decodeErr.Format)
+
+	_, err = OutputYAML[outputWidget](client.Command("widget", "get"))
+	assert.ErrorContains(t, err, "exit status 1")
+}
+
+func TestCmd_OutputLines(t *testing.T) {
+	client := NewClient().WithStubbing()
+	defer client.VerifyStubs(t)
+
+	client.RegisterStub(MatchArgv("git", "branch"), LinesResponse("main", "feature"))
+	client.RegisterStub(MatchArgv("git", "branch"), StringResponse("main\r\nfeature\r\n"))
+	client.RegisterStub(MatchArgv("git", "branch"), LinesResponse())
+	client.RegisterStub(MatchArgv("git", "branch"), ErrorResponse(NewExitError(1)))
+
+	lines, err := client.Command("git", "branch").OutputLines()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"main", "feature"}, lines)
+
+	lines, err = client.Command("git", "branch").OutputLines()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"main", "feature"}, lines)
+
+	lines, err = client.Command("git", "branch").OutputLines()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, lines)
+
+	_, err = client.Command("git", "branch").OutputLines()
+	assert.ErrorContains(t, err, "exit status 1")
+}
+
+func TestCmd_OutputCSV(t *testing.T) {
+	client := NewClient().WithStubbing()
+	defer client.VerifyStubs(t)
+
+	client.RegisterStub(MatchArgv("report"), LinesResponse("name,count", `"foo, bar",2`))
+	client.RegisterStub(MatchArgv("report"), LinesResponse(`"unterminated`))
+	client.RegisterStub(MatchArgv("report"), ErrorResponse(NewExitError(1)))
+
+	records, err := client.Command("report").OutputCSV()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"name", "count"}, {"foo, bar", "2"}}, records)
+
+	_, err = client.Command("report").OutputCSV()
+	var decodeErr *DecodeError
+	assert.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, "csv", decodeErr.Format)
+
+	_, err = client.Command("report").OutputCSV()
+	assert.ErrorContains(t, err, "exit status 1")
+}
+
+func TestDecodeError(t *testing.T) {
+	cmd := NewClient().Command("widget", "get")
+	err := newDecodeError(cmd, "json", []byte(strings.Repeat("x", 150)), errors.New("boom"))
+	assert.Equal(t, strings.Repeat("x", 100)+"…", err.Snippet)
+	assert.ErrorContains(t, err, "boom")
+	assert.Equal(t, "boom", errors.Unwrap(err).Error())
+
+	// Multi-byte characters aren't split.
+	err = newDecodeError(cmd, "json", []byte(strings.Repeat("x", 99)+"éé"), errors.New("boom"))
+	assert.Equal(t, strings.Repeat("x", 99)+"…", err.Snippet)
+}
@@ -0,0 +1,22 @@
+//go:build windows
+
+package run
+
+import (
+	"os"
+	"os/exec"
+)
+
+// Windows has no notion of signals beyond kill.
+var defaultTerminateSignal os.Signal = os.Kill
+
+func setProcessGroup(cmd *exec.Cmd) {
+}
+
+func signalProcessGroup(p *os.Process, sig os.Signal) error {
+	return p.Kill()
+}
+
+func killProcessGroup(p *os.Process) error {
+	return p.Kill()
+}
@@ -0,0 +1,213 @@
+package run
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/twelvelabs/termite/ui"
+)
+
+var (
+	// ErrPTYUnsupported is returned when pseudo-terminals are not supported
+	// on the current platform.
+	ErrPTYUnsupported = errors.New("run: pty not supported on this platform")
+	// ErrExpectTimeout is returned when [Console.Expect] times out.
+	ErrExpectTimeout = errors.New("timed out waiting for output")
+)
+
+type ptyOptions struct {
+	ios        *ui.IOStreams
+	transcript io.Writer
+}
+
+// WithPTY configures the command to run attached to a pseudo-terminal
+// (rather than plain pipes). The terminal is connected to ios:
+// input is forwarded from [ui.IOStreams.In] (in raw mode when it's a terminal),
+// output is copied to [ui.IOStreams.Out], and window size changes
+// are forwarded to the command.
+//
+// Output returns everything the command writes to the terminal
+// (stdout and stderr combined, with "\r\n" line endings), which is
+// also copied to [ui.IOStreams.Out].
+//
+// Only supported on Linux; elsewhere Run and Output return [ErrPTYUnsupported].
+func (c *Cmd) WithPTY(ios *ui.IOStreams) *Cmd {
+	if c.pty == nil {
+		c.pty = &ptyOptions{}
+	}
+	c.pty.ios = ios
+	return c
+}
+
+// WithTranscript copies everything written to the command's
+// pseudo-terminal to w. Has no effect unless [Cmd.WithPTY] is also used.
+func (c *Cmd) WithTranscript(w io.Writer) *Cmd {
+	if c.pty == nil {
+		c.pty = &ptyOptions{}
+	}
+	c.pty.transcript = w
+	return c
+}
+
+// StartConsole starts the command attached to a new pseudo-terminal
+// and returns a Console for scripting it, expect style.
+//
+// Note that consoles always run the real command: [Client.Executor] is bypassed.
+func (c *Cmd) StartConsole() (*Console, error) {
+	master, err := startPTY(c)
+	if err != nil {
+		return nil, err
+	}
+	console := &Console{
+		cmd:    c,
+		master: master,
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	if c.pty != nil && c.pty.transcript != nil {
+		console.transcript = c.pty.transcript
+	}
+	go console.read()
+	return console, nil
+}
+
+// Console is a pseudo-terminal attached to a running command.
+// It allows tests to drive interactive programs.
+//
+// For example:
+//
+//	console, _ := client.Command("ssh-keygen").StartConsole()
+//	console.Expect(`Enter file in which to save the key.*: `, time.Second)
+//	console.SendLine("/tmp/id_test")
+//	console.Wait()
+type Console struct {
+	cmd        *Cmd
+	master     *os.File
+	transcript io.Writer
+
+	mu      sync.Mutex
+	buf     bytes.Buffer // unconsumed output
+	all     bytes.Buffer // all output
+	readErr error
+	notify  chan struct{}
+	done    chan struct{}
+}
+
+// Expect waits until the console output matches the regular expression
+// pattern, and returns the matched text. Output up to the end of the match
+// is consumed, so subsequent calls only see new output.
+func (c *Console) Expect(pattern string, timeout time.Duration) (string, error) {
+	r, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		c.mu.Lock()
+		data := c.buf.Bytes()
+		if loc := r.FindIndex(data); loc != nil {
+			match := string(data[loc[0]:loc[1]])
+			c.buf.Next(loc[1])
+			c.mu.Unlock()
+			return match, nil
+		}
+		readErr := c.readErr
+		unconsumed := c.buf.String()
+		c.mu.Unlock()
+
+		if readErr != nil {
+			return "", fmt.Errorf("expect %q: %w [Output: %q]", pattern, readErr, unconsumed)
+		}
+
+		select {
+		case <-c.notify:
+		case <-timer.C:
+			return "", fmt.Errorf("expect %q: %w [Output: %q]", pattern, ErrExpectTimeout, unconsumed)
+		}
+	}
+}
+
+// Send writes s to the console, as if typed by the user.
+func (c *Console) Send(s string) error {
+	_, err := io.WriteString(c.master, s)
+	return err
+}
+
+// SendLine writes s followed by a newline to the console.
+func (c *Console) SendLine(s string) error {
+	return c.Send(s + "\n")
+}
+
+// Resize sets the window size of the console.
+func (c *Console) Resize(rows int, cols int) error {
+	return setPTYSize(c.master, rows, cols)
+}
+
+// Output returns all output written to the console so far.
+func (c *Console) Output() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.all.String()
+}
+
+// Wait waits for the command to exit and closes the console.
+func (c *Console) Wait() error {
+	err := c.cmd.Cmd.Wait()
+	<-c.done
+	_ = c.master.Close()
+	return err
+}
+
+// Close kills the command (if still running) and closes the console.
+func (c *Console) Close() error {
+	if c.cmd.ProcessState == nil {
+		_ = c.cmd.Process.Kill()
+		_ = c.cmd.Cmd.Wait()
+	}
+	err := c.master.Close()
+	<-c.done
+	return err
+}
+
+// read copies output from the terminal into the console buffers.
+func (c *Console) read() {
+	defer close(c.done)
+	chunk := make([]byte, 4096)
+	for {
+		n, err := c.master.Read(chunk)
+		c.mu.Lock()
+		if n > 0 {
+			c.buf.Write(chunk[:n])
+			c.all.Write(chunk[:n])
+			if c.transcript != nil {
+				_, _ = c.transcript.Write(chunk[:n])
+			}
+		}
+		if err != nil {
+			c.readErr = err
+			if errors.Is(err, syscall.EIO) {
+				// Linux returns EIO once the terminal has been closed.
+				c.readErr = io.EOF
+			}
+		}
+		c.mu.Unlock()
+
+		select {
+		case c.notify <- struct{}{}:
+		default:
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,230 @@
+//go:build linux
+
+package run
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+
+	"github.com/twelvelabs/termite/ui"
+)
+
+// runPTY runs cmd attached to a pseudo-terminal connected to cmd.pty.ios.
+// If capture is not nil, the terminal output is also copied to it.
+func (e *defaultExecutor) runPTY(cmd *Cmd, capture io.Writer) error {
+	ios := cmd.pty.ios
+	if ios == nil {
+		ios = ui.NewIOStreams()
+	}
+
+	ctx, cancel := cmd.startContext()
+	defer cancel()
+
+	sup := supervise(ctx, cmd)
+	master, err := startPTY(cmd)
+	if err != nil {
+		sup.stop()
+		return err
+	}
+	defer master.Close()
+
+	if ui.IsTerminal(ios.Out) {
+		stop := forwardResize(int(ios.Out.Fd()), master)
+		defer stop()
+	}
+	if ui.IsTerminal(ios.In) {
+		state, err := term.MakeRaw(int(ios.In.Fd()))
+		if err == nil {
+			defer func() {
+				_ = term.Restore(int(ios.In.Fd()), state)
+			}()
+		}
+	}
+
+	stopInput := copyInput(master, ios.In)
+
+	writers := []io.Writer{ios.Out}
+	if cmd.pty.transcript != nil {
+		writers = append(writers, cmd.pty.transcript)
+	}
+	if capture != nil {
+		writers = append(writers, capture)
+	}
+	out := io.MultiWriter(writers...)
+	copied := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(out, master)
+		close(copied)
+	}()
+
+	err = sup.wait()
+	stopInput()
+	<-copied
+	return err
+}
+
+// copyInput copies in to master until in is exhausted
+// or the returned func is called.
+//
+// File backed streams (i.e. a terminal) are read via a dup of the fd,
+// polled alongside a pipe that is closed to stop the copy, so no reads
+// are left pending (and no input swallowed) once the command exits.
+func copyInput(master *os.File, in ui.IOStream) func() {
+	done := make(chan struct{})
+	if _, ok := in.(interface{ Stat() (os.FileInfo, error) }); !ok {
+		// In memory streams never block.
+		go func() {
+			defer close(done)
+			_, _ = io.Copy(master, in)
+		}()
+		return func() { <-done }
+	}
+
+	fd, err := unix.Dup(int(in.Fd()))
+	if err != nil {
+		return func() {}
+	}
+	var stop [2]int
+	if err := unix.Pipe2(stop[:], unix.O_CLOEXEC); err != nil {
+		_ = unix.Close(fd)
+		return func() {}
+	}
+
+	go func() {
+		defer close(done)
+		defer unix.Close(fd)
+		defer unix.Close(stop[0])
+
+		buf := make([]byte, 4096)
+		for {
+			fds := []unix.PollFd{
+				{Fd: int32(fd), Events: unix.POLLIN},      //nolint:gosec
+				{Fd: int32(stop[0]), Events: unix.POLLIN}, //nolint:gosec
+			}
+			if _, err := unix.Poll(fds, -1); err != nil {
+				if err == unix.EINTR {
+					continue
+				}
+				return
+			}
+			if fds[1].Revents != 0 {
+				return
+			}
+			if fds[0].Revents == 0 {
+				continue
+			}
+			n, err := unix.Read(fd, buf)
+			if err == unix.EINTR || err == unix.EAGAIN {
+				continue
+			}
+			if n <= 0 || err != nil {
+				return
+			}
+			if _, err := master.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+	}()
+	return func() {
+		_ = unix.Close(stop[1])
+		<-done
+	}
+}
+
+// startPTY starts cmd with a new pseudo-terminal as its controlling terminal
+// and returns the master side.
+func startPTY(cmd *Cmd) (*os.File, error) {
+	master, slave, err := openPTY()
+	if err != nil {
+		return nil, err
+	}
+	defer slave.Close()
+
+	cmd.Stdin = slave
+	cmd.Stdout = slave
+	cmd.Stderr = slave
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	// A new session also puts the command in a new process group.
+	cmd.SysProcAttr.Setpgid = false
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+	cmd.SysProcAttr.Ctty = 0
+
+	if err := cmd.Cmd.Start(); err != nil {
+		_ = master.Close()
+		return nil, err
+	}
+	return master, nil
+}
+
+// openPTY opens a new pseudo-terminal pair.
+func openPTY() (*os.File, *os.File, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open pty: %w", err)
+	}
+	fd := int(master.Fd())
+
+	// Unlock the slave and look up its name.
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		_ = master.Close()
+		return nil, nil, fmt.Errorf("unlock pty: %w", err)
+	}
+	n, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		_ = master.Close()
+		return nil, nil, fmt.Errorf("pty name: %w", err)
+	}
+
+	slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		_ = master.Close()
+		return nil, nil, fmt.Errorf("open pty: %w", err)
+	}
+	return master, slave, nil
+}
+
+// setPTYSize sets the window size of the terminal.
+func setPTYSize(f *os.File, rows int, cols int) error {
+	return unix.IoctlSetWinsize(int(f.Fd()), unix.TIOCSWINSZ, &unix.Winsize{
+		Row: uint16(rows), //nolint:gosec
+		Col: uint16(cols), //nolint:gosec
+	})
+}
+
+// forwardResize copies the window size of the terminal at fd to master,
+// now and whenever SIGWINCH is received. Call the returned func to stop.
+func forwardResize(fd int, master *os.File) func() {
+	resize := func() {
+		if ws, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ); err == nil {
+			_ = unix.IoctlSetWinsize(int(master.Fd()), unix.TIOCSWINSZ, ws)
+		}
+	}
+	resize()
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGWINCH)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ch:
+				resize()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
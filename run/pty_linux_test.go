@@ -0,0 +1,188 @@
+//go:build linux
+
+package run
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+
+	"github.com/twelvelabs/termite/ui"
+)
+
+func TestDefaultExecutor_RunWithPTY(t *testing.T) {
+	ios := ui.NewTestIOStreams()
+	_, _ = ios.In.Write([]byte("hello\n"))
+
+	transcript := &bytes.Buffer{}
+	cmd := NewClient().
+		Command("sh", "-c", `read x; echo "got $x"; test -t 0 && echo "is a tty"`).
+		WithPTY(ios).
+		WithTranscript(transcript)
+	err := cmd.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, cmd.ExitCode())
+
+	assert.Contains(t, ios.Out.String(), "got hello")
+	assert.Contains(t, ios.Out.String(), "is a tty")
+	assert.Equal(t, ios.Out.String(), transcript.String())
+}
+
+// fileIOStream is an IOStream backed by a real file.
+type fileIOStream struct {
+	*os.File
+}
+
+func (f *fileIOStream) String() string  { return "" }
+func (f *fileIOStream) Lines() []string { return nil }
+
+func TestDefaultExecutor_OutputWithPTY(t *testing.T) {
+	ios := ui.NewTestIOStreams()
+	transcript := &bytes.Buffer{}
+	out, err := NewClient().
+		Command("sh", "-c", "[ -t 1 ] && echo tty || echo notty").
+		WithPTY(ios).
+		WithTranscript(transcript).
+		Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "tty\r\n", string(out))
+	assert.Equal(t, "tty\r\n", ios.Out.String())
+	assert.Equal(t, "tty\r\n", transcript.String())
+
+	// Including when the command has a timeout.
+	out, err = NewClient().
+		Command("sh", "-c", "[ -t 1 ] && echo tty || echo notty").
+		WithPTY(ui.NewTestIOStreams()).
+		WithTimeout(time.Minute).
+		Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "tty\r\n", string(out))
+}
+
+func TestDefaultExecutor_RunWithPTYStopsCopyingInput(t *testing.T) {
+	r, w, err := os.Pipe()
+	assert.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+
+	ios := ui.NewTestIOStreams()
+	ios.In = &fileIOStream{File: r}
+	assert.NoError(t, NewClient().Command("true").WithPTY(ios).Run())
+
+	// Input written after the command exits should not be consumed.
+	_, err = w.Write([]byte("x"))
+	assert.NoError(t, err)
+	buf := make([]byte, 1)
+	_, err = r.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "x", string(buf))
+}
+
+func TestDefaultExecutor_RunWithPTYWhenExitError(t *testing.T) {
+	ios := ui.NewTestIOStreams()
+	cmd := NewClient().Command("sh", "-c", "exit 3").WithPTY(ios)
+	err := cmd.Run()
+	assert.ErrorContains(t, err, "exit status 3")
+	assert.Equal(t, 3, cmd.ExitCode())
+}
+
+func TestDefaultExecutor_RunWithPTYAndTimeout(t *testing.T) {
+	ios := ui.NewTestIOStreams()
+	cmd := NewClient().
+		Command("sleep", "10").
+		WithPTY(ios).
+		WithTimeout(50 * time.Millisecond)
+	err := cmd.Run()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestDefaultExecutor_RunWithPTYWhenStartError(t *testing.T) {
+	ios := ui.NewTestIOStreams()
+	cmd := NewClient().Command("/does/not/exist").WithPTY(ios)
+	assert.Error(t, cmd.Run())
+}
+
+func TestConsole(t *testing.T) {
+	transcript := &bytes.Buffer{}
+	cmd := NewClient().
+		Command("sh", "-c", `printf "name? "; read n; echo "hi $n"; stty size`).
+		WithTranscript(transcript)
+	console, err := cmd.StartConsole()
+	assert.NoError(t, err)
+	assert.NoError(t, console.Resize(40, 120))
+
+	match, err := console.Expect(`name\? `, 5*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "name? ", match)
+
+	assert.NoError(t, console.SendLine("bob"))
+	match, err = console.Expect(`hi \w+`, 5*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "hi bob", match)
+
+	_, err = console.Expect(`40 120`, 5*time.Second)
+	assert.NoError(t, err)
+
+	// Output has already been consumed.
+	_, err = console.Expect(`hi bob`, 100*time.Millisecond)
+	assert.Error(t, err)
+
+	assert.NoError(t, console.Wait())
+	assert.Contains(t, console.Output(), "hi bob")
+	assert.Equal(t, console.Output(), transcript.String())
+}
+
+func TestConsole_ExpectErrors(t *testing.T) {
+	console, err := NewClient().Command("sh", "-c", "echo done").StartConsole()
+	assert.NoError(t, err)
+
+	_, err = console.Expect(`(`, time.Second)
+	assert.ErrorContains(t, err, "error parsing regexp")
+
+	_, err = console.Expect(`never`, 5*time.Second)
+	assert.ErrorContains(t, err, "EOF")
+	assert.NoError(t, console.Wait())
+
+	console, err = NewClient().Command("sleep", "10").StartConsole()
+	assert.NoError(t, err)
+	_, err = console.Expect(`never`, 10*time.Millisecond)
+	assert.ErrorIs(t, err, ErrExpectTimeout)
+	assert.NoError(t, console.Close())
+}
+
+func TestStartConsole_WhenStartError(t *testing.T) {
+	_, err := NewClient().Command("/does/not/exist").StartConsole()
+	assert.Error(t, err)
+}
+
+func TestPTYSize(t *testing.T) {
+	master, slave, err := openPTY()
+	assert.NoError(t, err)
+	defer master.Close()
+	defer slave.Close()
+
+	assert.NoError(t, setPTYSize(master, 24, 80))
+	ws, err := unix.IoctlGetWinsize(int(slave.Fd()), unix.TIOCGWINSZ)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(24), ws.Row)
+	assert.Equal(t, uint16(80), ws.Col)
+
+	// Copies size from the "terminal" to master.
+	assert.NoError(t, setPTYSize(slave, 50, 132))
+	other, otherSlave, err := openPTY()
+	assert.NoError(t, err)
+	defer other.Close()
+	defer otherSlave.Close()
+
+	stop := forwardResize(int(slave.Fd()), other)
+	stop()
+	ws, err = unix.IoctlGetWinsize(int(otherSlave.Fd()), unix.TIOCGWINSZ)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(50), ws.Row)
+	assert.Equal(t, uint16(132), ws.Col)
+}
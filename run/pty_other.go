@@ -0,0 +1,20 @@
+//go:build !linux
+
+package run
+
+import (
+	"io"
+	"os"
+)
+
+func (e *defaultExecutor) runPTY(cmd *Cmd, capture io.Writer) error {
+	return ErrPTYUnsupported
+}
+
+func startPTY(cmd *Cmd) (*os.File, error) {
+	return nil, ErrPTYUnsupported
+}
+
+func setPTYSize(f *os.File, rows int, cols int) error {
+	return ErrPTYUnsupported
+}
@@ -0,0 +1,113 @@
+package run
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	_ Executor = &RecordingExecutor{}
+)
+
+// NewRecordingExecutor returns a new RecordingExecutor that wraps executor.
+// If executor is nil, [DefaultExecutor] is used.
+func NewRecordingExecutor(executor Executor) *RecordingExecutor {
+	if executor == nil {
+		executor = DefaultExecutor
+	}
+	return &RecordingExecutor{
+		Executor:   executor,
+		Recordings: []*Recording{},
+	}
+}
+
+// RecordingExecutor is an implementation of Executor that records each command
+// executed by the wrapped executor. Recordings can be saved to a fixture file
+// and later replayed via [NewReplayExecutor].
+// Secrets (see [Cmd.WithSecret]) are redacted from recordings.
+type RecordingExecutor struct {
+	Executor   Executor
+	Recordings []*Recording
+
+	mu sync.Mutex
+}
+
+func (e *RecordingExecutor) wrapped() Executor {
+	return e.Executor
+}
+
+func (e *RecordingExecutor) ExitCode(cmd *Cmd) int {
+	return e.Executor.ExitCode(cmd)
+}
+
+func (e *RecordingExecutor) Output(cmd *Cmd) ([]byte, error) {
+	return captureOutput(cmd, e.Run)
+}
+
+func (e *RecordingExecutor) Run(cmd *Cmd) error {
+	var stdin []byte
+	if _, ok := cmd.Stdin.(*os.File); !ok {
+		// Don't try to slurp a terminal or other OS level stream.
+		stdin, _ = cmd.PeekStdin()
+	}
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	origStdout, origStderr := cmd.Stdout, cmd.Stderr
+	cmd.Stdout = teeWriter(origStdout, &stdout)
+	cmd.Stderr = teeWriter(origStderr, &stderr)
+
+	start := time.Now()
+	err := e.Executor.Run(cmd)
+	duration := time.Since(start)
+
+	cmd.Stdout, cmd.Stderr = origStdout, origStderr
+
+	env, unset := envDiff(cmd)
+	recording := &Recording{
+		Args:     cmd.redactAll(cmd.Args),
+		Dir:      cmd.Dir,
+		Env:      env,
+		EnvUnset: unset,
+		Stdin:    cmd.Redact(string(stdin)),
+		Stdout:   cmd.Redact(stdout.String()),
+		Stderr:   cmd.Redact(stderr.String()),
+		ExitCode: e.Executor.ExitCode(cmd),
+		Duration: duration,
+	}
+	if err != nil && recording.ExitCode <= 0 {
+		// The command never ran (or was terminated).
+		recording.Error = cmd.Redact(err.Error())
+	}
+
+	e.mu.Lock()
+	e.Recordings = append(e.Recordings, recording)
+	e.mu.Unlock()
+
+	return err
+}
+
+// Fixture returns a fixture containing all recordings made so far.
+func (e *RecordingExecutor) Fixture() *Fixture {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return &Fixture{
+		Recordings: append([]*Recording{}, e.Recordings...),
+	}
+}
+
+// Save writes all recordings made so far to the fixture file at path.
+func (e *RecordingExecutor) Save(path string) error {
+	return WriteFixture(path, e.Fixture())
+}
+
+// teeWriter returns a writer that duplicates writes to w (if set) and capture.
+func teeWriter(w io.Writer, capture io.Writer) io.Writer {
+	if w == nil {
+		return capture
+	}
+	return io.MultiWriter(w, capture)
+}
@@ -0,0 +1,91 @@
+package run
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRecordingExecutor(t *testing.T) {
+	executor := NewRecordingExecutor(nil)
+	assert.Equal(t, DefaultExecutor, executor.Executor)
+
+	stub := NewStubExecutor()
+	executor = NewRecordingExecutor(stub)
+	assert.Equal(t, stub, executor.Executor)
+}
+
+func TestRecordingExecutor_Run(t *testing.T) {
+	executor := NewRecordingExecutor(nil)
+	client := &Client{Executor: executor}
+
+	buf, err := client.Command("echo", "foo", "bar").Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "foo bar\n", string(buf))
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd := client.Command("sh", "-c", "cat; echo oops >&2; exit 3")
+	cmd.Stdin = bytes.NewBufferString("howdy")
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.Env = append(cmd.Environ(), "RECORDING_EXECUTOR_TEST=1")
+	err = cmd.Run()
+	assert.ErrorContains(t, err, "exit status 3")
+	assert.Equal(t, 3, cmd.ExitCode())
+	// Output should still be delivered to the caller.
+	assert.Equal(t, "howdy", stdout.String())
+	assert.Equal(t, "oops\n", stderr.String())
+
+	err = client.Command("/does/not/exist").Run()
+	assert.Error(t, err)
+
+	assert.Len(t, executor.Recordings, 3)
+
+	rec := executor.Recordings[0]
+	assert.Equal(t, []string{"echo", "foo", "bar"}, rec.Args)
+	assert.Equal(t, "foo bar\n", rec.Stdout)
+	assert.Equal(t, 0, rec.ExitCode)
+	assert.Nil(t, rec.Env)
+	assert.Greater(t, rec.Duration.Nanoseconds(), int64(0))
+
+	rec = executor.Recordings[1]
+	assert.Equal(t, "howdy", rec.Stdin)
+	assert.Equal(t, "howdy", rec.Stdout)
+	assert.Equal(t, "oops\n", rec.Stderr)
+	assert.Equal(t, 3, rec.ExitCode)
+	assert.Equal(t, "", rec.Error)
+	assert.Equal(t, "1", rec.Env["RECORDING_EXECUTOR_TEST"])
+
+	rec = executor.Recordings[2]
+	assert.Equal(t, -1, rec.ExitCode)
+	assert.Contains(t, rec.Error, "no such file or directory")
+}
+
+func TestRecordingExecutor_SaveAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.yaml")
+
+	recorder := NewRecordingExecutor(nil)
+	client := &Client{Executor: recorder}
+	_, _ = client.Command("echo", "foo").Output()
+	_, _ = client.Command("sh", "-c", "echo oops >&2; exit 3").Output()
+	assert.NoError(t, recorder.Save(path))
+
+	replayer, err := NewReplayExecutor(path)
+	assert.NoError(t, err)
+	client = &Client{Executor: replayer}
+
+	buf, err := client.Command("echo", "foo").Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "foo\n", string(buf))
+
+	cmd := client.Command("sh", "-c", "echo oops >&2; exit 3")
+	_, err = cmd.Output()
+	assert.ErrorContains(t, err, "exit status 3")
+	assert.Equal(t, 3, cmd.ExitCode())
+	assert.Equal(t, "oops\n", string(err.(*ExitError).Stderr))
+
+	replayer.VerifyStubs(t)
+}
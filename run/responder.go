@@ -1,13 +1,62 @@
 package run
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"regexp"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+var (
+	jsonMarshal = json.Marshal
+	yamlMarshal = yaml.Marshal
 )
 
 // Responder is a function that returns stubbed command output.
 type Responder func(cmd *Cmd) (stdout []byte, stderr []byte, err error)
 
+// DelayResponse wraps a responder so that it waits for d before responding.
+// Useful for exercising [Cmd.WithTimeout]: if the command times out or is
+// canceled first, the wait is abandoned and the context error is returned.
+func DelayResponse(d time.Duration, responder Responder) Responder {
+	return func(cmd *Cmd) ([]byte, []byte, error) {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return responder(cmd)
+		case <-cmd.Context().Done():
+			return nil, nil, cmd.Context().Err()
+		}
+	}
+}
+
+// HangResponse creates a responder that simulates a hung command.
+// It blocks until the command times out or is canceled, so the command
+// must have a timeout or context (otherwise an error is returned).
+func HangResponse() Responder {
+	return func(cmd *Cmd) ([]byte, []byte, error) {
+		if !cmd.isCancelable() {
+			return nil, nil, errors.New("run: HangResponse requires a command with a timeout or context")
+		}
+		<-cmd.Context().Done()
+		return nil, nil, cmd.Context().Err()
+	}
+}
+
+// WithDuration wraps a responder so that the command reports
+// a wall time of d in its [Result] (without actually waiting).
+func WithDuration(d time.Duration, responder Responder) Responder {
+	return func(cmd *Cmd) ([]byte, []byte, error) {
+		cmd.stubDuration.Store(int64(d))
+		return responder(cmd)
+	}
+}
+
 // ErrorResponse creates a responder that returns err.
 func ErrorResponse(err error) Responder {
 	return func(cmd *Cmd) ([]byte, []byte, error) {
@@ -15,6 +64,39 @@ func ErrorResponse(err error) Responder {
 	}
 }
 
+// JSONResponse creates a responder that serializes data as JSON via stdout.
+func JSONResponse(data any) Responder {
+	return func(cmd *Cmd) ([]byte, []byte, error) {
+		b, err := jsonMarshal(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		return b, nil, nil
+	}
+}
+
+// YAMLResponse creates a responder that serializes data as YAML via stdout.
+func YAMLResponse(data any) Responder {
+	return func(cmd *Cmd) ([]byte, []byte, error) {
+		b, err := yamlMarshal(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		return b, nil, nil
+	}
+}
+
+// LinesResponse creates a responder that returns each line
+// (newline terminated) via stdout.
+func LinesResponse(lines ...string) Responder {
+	return func(cmd *Cmd) ([]byte, []byte, error) {
+		if len(lines) == 0 {
+			return []byte{}, nil, nil
+		}
+		return []byte(strings.Join(lines, "\n") + "\n"), nil, nil
+	}
+}
+
 // RegexpResponse creates a responder that returns the match index
 // for the given regular expression pattern.
 // Panics if there is no match for index.
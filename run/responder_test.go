@@ -1,9 +1,12 @@
 package run
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/prashantv/gostub" // spell: disable-line
 	"github.com/stretchr/testify/assert"
 )
 
@@ -99,3 +102,112 @@ func TestMuxResponse_NonZero(t *testing.T) {
 	assert.Equal(t, "bar", string(stderr))
 	assert.ErrorContains(t, err, "exit status 123")
 }
+
+func TestDelayResponse(t *testing.T) {
+	cmd := NewClient().Command("/bin/echo")
+	responder := DelayResponse(time.Millisecond, StringResponse("foo"))
+	stdout, stderr, err := responder(cmd)
+	assert.Equal(t, "foo", string(stdout))
+	assert.Nil(t, stderr)
+	assert.NoError(t, err)
+}
+
+func TestDelayResponse_WhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cmd := NewClient().CommandContext(ctx, "/bin/echo")
+	responder := DelayResponse(time.Hour, StringResponse("foo"))
+	stdout, stderr, err := responder(cmd)
+	assert.Nil(t, stdout)
+	assert.Nil(t, stderr)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestHangResponse(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cmd := NewClient().CommandContext(ctx, "/bin/echo")
+	responder := HangResponse()
+	stdout, stderr, err := responder(cmd)
+	assert.Nil(t, stdout)
+	assert.Nil(t, stderr)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// Fails fast when the command can never time out.
+	cmd = NewClient().Command("/bin/echo")
+	_, _, err = responder(cmd)
+	assert.EqualError(t, err, "run: HangResponse requires a command with a timeout or context")
+}
+
+func TestJSONResponse(t *testing.T) {
+	cmd := NewClient().Command("/bin/echo")
+	responder := JSONResponse(map[string]any{"foo": "bar"})
+	stdout, stderr, err := responder(cmd)
+	assert.Equal(t, `{"foo":"bar"}`, string(stdout))
+	assert.Nil(t, stderr)
+	assert.NoError(t, err)
+}
+
+func TestJSONResponse_WhenMarshalError(t *testing.T) {
+	stubs := gostub.StubFunc(&jsonMarshal, nil, errors.New("boom"))
+	defer stubs.Reset()
+
+	cmd := NewClient().Command("/bin/echo")
+	responder := JSONResponse(map[string]any{"foo": "bar"})
+	stdout, stderr, err := responder(cmd)
+	assert.Nil(t, stdout)
+	assert.Nil(t, stderr)
+	assert.ErrorContains(t, err, "boom")
+}
+
+func TestYAMLResponse(t *testing.T) {
+	cmd := NewClient().Command("/bin/echo")
+	responder := YAMLResponse(map[string]any{"foo": "bar"})
+	stdout, stderr, err := responder(cmd)
+	assert.Equal(t, "foo: bar\n", string(stdout))
+	assert.Nil(t, stderr)
+	assert.NoError(t, err)
+}
+
+func TestYAMLResponse_WhenMarshalError(t *testing.T) {
+	stubs := gostub.StubFunc(&yamlMarshal, nil, errors.New("boom"))
+	defer stubs.Reset()
+
+	cmd := NewClient().Command("/bin/echo")
+	responder := YAMLResponse(map[string]any{"foo": "bar"})
+	_, _, err := responder(cmd)
+	assert.ErrorContains(t, err, "boom")
+}
+
+func TestLinesResponse(t *testing.T) {
+	cmd := NewClient().Command("/bin/echo")
+	stdout, stderr, err := LinesResponse("foo", "bar")(cmd)
+	assert.Equal(t, "foo\nbar\n", string(stdout))
+	assert.Nil(t, stderr)
+	assert.NoError(t, err)
+
+	stdout, _, _ = LinesResponse()(cmd)
+	assert.Equal(t, "", string(stdout))
+}
+
+func TestWithDuration(t *testing.T) {
+	cmd := NewClient().Command("/bin/echo")
+	responder := WithDuration(3*time.Second, StringResponse("ok"))
+	stdout, _, err := responder(cmd)
+	assert.Equal(t, "ok", string(stdout))
+	assert.NoError(t, err)
+	assert.Equal(t, 3*time.Second, time.Duration(cmd.stubDuration.Load()))
+}
+
+func TestWithDuration_WhenTimedOut(t *testing.T) {
+	// The responder runs in its own goroutine when the command can time out
+	// (run w/ -race to verify the duration isn't a data race).
+	client := NewClient().WithStubbing()
+	client.RegisterStub(MatchAny, WithDuration(time.Second, HangResponse()))
+
+	cmd := client.Command("/bin/echo").WithTimeout(10 * time.Millisecond)
+	err := cmd.Run()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
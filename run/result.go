@@ -0,0 +1,124 @@
+package run
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Result contains the exit status, timing, and resource usage
+// of a completed command.
+type Result struct {
+	// Args are the command line arguments (with secrets redacted).
+	Args []string
+	// Dir is the working directory of the command.
+	Dir string
+	// ExitCode is the exit code of the command (see [Cmd.ExitCode]).
+	ExitCode int
+	// Err is the error returned when running the command (if any).
+	Err error
+	// StartedAt is when the command was started.
+	StartedAt time.Time
+	// WallTime is the elapsed real time.
+	WallTime time.Duration
+	// UserTime is the user CPU time of the process.
+	UserTime time.Duration
+	// SystemTime is the system CPU time of the process.
+	SystemTime time.Duration
+	// MaxRSS is the maximum resident set size of the process, in bytes.
+	// Only available on Unix systems.
+	MaxRSS int64
+}
+
+// String returns a short summary of the result (i.e. "1.2s git status").
+func (r *Result) String() string {
+	return fmt.Sprintf("%v %s", r.WallTime.Round(time.Millisecond), strings.Join(r.Args, " "))
+}
+
+// Results is a collection of command results.
+type Results []*Result
+
+// Slowest returns (at most) the n results with the longest wall time,
+// slowest first.
+func (rs Results) Slowest(n int) Results {
+	sorted := append(Results{}, rs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].WallTime > sorted[j].WallTime
+	})
+	if n < len(sorted) {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// WallTime returns the total wall time of all results.
+func (rs Results) WallTime() time.Duration {
+	var total time.Duration
+	for _, r := range rs {
+		total += r.WallTime
+	}
+	return total
+}
+
+// ResultCollector collects command results.
+// Intended for use as a [Client.ResultHook]:
+//
+//	collector := &run.ResultCollector{}
+//	client.ResultHook = collector.Collect
+//	// ... run some commands
+//	for _, r := range collector.Results().Slowest(5) {
+//		fmt.Println(r)
+//	}
+type ResultCollector struct {
+	mu      sync.Mutex
+	results Results
+}
+
+// Collect adds r to the collection.
+func (c *ResultCollector) Collect(r *Result) {
+	c.mu.Lock()
+	c.results = append(c.results, r)
+	c.mu.Unlock()
+}
+
+// Results returns the collected results.
+func (c *ResultCollector) Results() Results {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append(Results{}, c.results...)
+}
+
+// Result returns the result of running the command,
+// or nil if it has not been run via [Cmd.Run] or [Cmd.Output].
+func (c *Cmd) Result() *Result {
+	return c.result
+}
+
+// finish records the result of running the command and passes it
+// to the client result hook (if any).
+func (c *Cmd) finish(start time.Time, err error) {
+	result := &Result{
+		Args:      c.redactAll(c.Args),
+		Dir:       c.Dir,
+		ExitCode:  c.exitCode,
+		Err:       err,
+		StartedAt: start,
+		WallTime:  time.Since(start),
+	}
+	if d := time.Duration(c.stubDuration.Load()); d > 0 {
+		result.WallTime = d
+	}
+	if ps := c.ProcessState; ps != nil {
+		result.ExitCode = ps.ExitCode()
+		result.UserTime = ps.UserTime()
+		result.SystemTime = ps.SystemTime()
+		result.MaxRSS = maxRSS(ps)
+	}
+	c.result = result
+
+	if c.client.ResultHook != nil {
+		c.client.ResultHook(result)
+	}
+}
@@ -0,0 +1,120 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twelvelabs/termite/ui"
+)
+
+func TestCmd_Result(t *testing.T) {
+	executor := NewStubExecutor()
+	executor.RegisterStub(
+		MatchArgv("git", "status"),
+		WithDuration(1200*time.Millisecond, StringResponse("clean")),
+	)
+	executor.RegisterStub(
+		MatchArgv("git", "push"),
+		ErrorResponse(NewExitError(1)),
+	)
+	client := &Client{Executor: executor}
+
+	cmd := client.Command("git", "status")
+	assert.Nil(t, cmd.Result())
+	_, err := cmd.Output()
+	assert.NoError(t, err)
+
+	result := cmd.Result()
+	assert.Equal(t, []string{"git", "status"}, result.Args)
+	assert.Equal(t, 0, result.ExitCode)
+	assert.NoError(t, result.Err)
+	assert.Equal(t, 1200*time.Millisecond, result.WallTime)
+	assert.False(t, result.StartedAt.IsZero())
+	assert.Equal(t, "1.2s git status", result.String())
+
+	cmd = client.Command("git", "push")
+	err = cmd.Run()
+	assert.Error(t, err)
+	assert.Equal(t, 1, cmd.Result().ExitCode)
+	assert.Equal(t, err, cmd.Result().Err)
+}
+
+func TestCmd_Result_Redacted(t *testing.T) {
+	executor := NewStubExecutor()
+	executor.RegisterStub(MatchAny, StringResponse(""))
+	client := &Client{Executor: executor}
+
+	cmd := client.Command("login", "--token", "s3cr3t").WithSecret("s3cr3t")
+	assert.NoError(t, cmd.Run())
+	assert.Equal(t, []string{"login", "--token", Redacted}, cmd.Result().Args)
+}
+
+func TestCmd_Result_WhenProcessExits(t *testing.T) {
+	client := NewClient()
+	cmd := client.Command("sh", "-c", "exit 3")
+	err := cmd.Run()
+	assert.Error(t, err)
+
+	result := cmd.Result()
+	assert.Equal(t, 3, result.ExitCode)
+	assert.Greater(t, result.WallTime, time.Duration(0))
+	assert.GreaterOrEqual(t, result.UserTime, time.Duration(0))
+	assert.GreaterOrEqual(t, result.SystemTime, time.Duration(0))
+	assert.GreaterOrEqual(t, result.MaxRSS, int64(0))
+}
+
+func TestClient_ResultHook(t *testing.T) {
+	executor := NewStubExecutor()
+	executor.RegisterStub(MatchAny, StringResponse(""))
+	executor.RegisterStub(MatchAny, StringResponse(""))
+	collector := &ResultCollector{}
+	client := &Client{Executor: executor, ResultHook: collector.Collect}
+
+	assert.NoError(t, client.Command("echo", "one").Run())
+	_, err := client.Command("echo", "two").Output()
+	assert.NoError(t, err)
+
+	results := collector.Results()
+	assert.Len(t, results, 2)
+	assert.Equal(t, []string{"echo", "one"}, results[0].Args)
+	assert.Equal(t, []string{"echo", "two"}, results[1].Args)
+}
+
+func TestResults_Slowest(t *testing.T) {
+	executor := NewStubExecutor()
+	collector := &ResultCollector{}
+	client := &Client{Executor: executor, ResultHook: collector.Collect}
+	for i, d := range []time.Duration{3, 1, 5, 2, 4, 6} {
+		name := fmt.Sprintf("cmd%d", i)
+		executor.RegisterStub(MatchArgv(name), WithDuration(d*time.Second, StringResponse("")))
+		assert.NoError(t, client.Command(name).Run())
+	}
+
+	results := collector.Results()
+	assert.Equal(t, 21*time.Second, results.WallTime())
+
+	ios := ui.NewTestIOStreams()
+	for _, r := range results.Slowest(3) {
+		fmt.Fprintln(ios.Out, r)
+	}
+	assert.Equal(t, []string{
+		"6s cmd5",
+		"5s cmd2",
+		"4s cmd4",
+	}, ios.Out.Lines())
+
+	assert.Len(t, results.Slowest(10), 6)
+	assert.Len(t, Results{}.Slowest(5), 0)
+}
+
+func TestResultCollector_Results(t *testing.T) {
+	collector := &ResultCollector{}
+	collector.Collect(&Result{Err: errors.New("boom")})
+	results := collector.Results()
+	results[0] = nil
+	assert.NotNil(t, collector.Results()[0])
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package run
+
+import (
+	"os"
+)
+
+func maxRSS(ps *os.ProcessState) int64 {
+	return 0
+}
@@ -0,0 +1,22 @@
+//go:build unix
+
+package run
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// maxRSS returns the max resident set size of the process, in bytes.
+func maxRSS(ps *os.ProcessState) int64 {
+	usage, ok := ps.SysUsage().(*syscall.Rusage)
+	if !ok || usage == nil {
+		return 0
+	}
+	if runtime.GOOS == "darwin" || runtime.GOOS == "ios" {
+		return int64(usage.Maxrss)
+	}
+	// Everywhere else reports kilobytes.
+	return int64(usage.Maxrss) * 1024
+}
@@ -0,0 +1,192 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrSandboxUnsupported is returned when the sandbox can not be created
+	// (i.e. the kernel does not allow unprivileged user namespaces).
+	ErrSandboxUnsupported = errors.New("sandbox unsupported")
+
+	sandboxSupported = cachedSandboxSupported
+	sandboxOnce      sync.Once
+	sandboxErr       error
+)
+
+// sandboxScript configures the sandbox and then replaces itself with the command.
+// Invoked as: sh -c sandboxScript sh <isolate> <workdir> <ulimit args> -- <command...>.
+//
+// When isolating, every mount (not just the root) is remounted read-only,
+// except for the bind mount of workdir. The existing per-mount flags
+// (i.e. nosuid) are kept, since they can't be cleared in a user namespace.
+// Mount points in mountinfo have octal escapes (i.e. "\040" for a space),
+// which are converted to the "\0040" form understood by printf %b.
+const sandboxScript = `set -e
+isolate="$1"; workdir="$2"; shift 2
+if [ -n "$isolate" ]; then
+	mount --make-rprivate / 2>/dev/null || true
+	if [ -n "$workdir" ]; then
+		workdir=$(cd "$workdir" && pwd -P)
+		mount --bind "$workdir" "$workdir"
+	fi
+	sed 's/\\\([0-7][0-7][0-7]\)/\\0\1/g' /proc/self/mountinfo |
+	while read -r _ _ _ _ mnt opts _; do
+		mnt=$(printf '%b.' "$mnt"); mnt="${mnt%.}"
+		[ "$mnt" = "$workdir" ] && continue
+		mount -o "remount,bind,ro$(echo "$opts" | sed 's/^r[ow]//')" "$mnt"
+	done
+	# re-resolve the working dir so it refers to the writable bind mount
+	cd "$PWD"
+fi
+while [ "$1" != "--" ]; do ulimit "$1" "$2"; shift 2; done
+shift
+exec "$@"`
+
+// NewSandboxExecutor returns a new SandboxExecutor that wraps executor.
+// If executor is nil, [DefaultExecutor] is used.
+func NewSandboxExecutor(executor Executor) *SandboxExecutor {
+	if executor == nil {
+		executor = DefaultExecutor
+	}
+	return &SandboxExecutor{
+		Executor: executor,
+	}
+}
+
+// SandboxExecutor is an implementation of Executor that constrains
+// the commands it runs. Intended for running untrusted scripts.
+//
+// Resource limits are applied via ulimit (requires a POSIX shell).
+// When Isolate is true, commands are run in new (unprivileged) user,
+// mount, and network namespaces with no network access and read-only
+// filesystems (including all mounts below the root, i.e. /tmp).
+// Only WorkDir (if set) remains writable. The command runs as root
+// inside the user namespace, mapped to the current user outside it.
+// Isolation is only supported on Linux; if the kernel does not allow it
+// an error wrapping [ErrSandboxUnsupported] is returned,
+// unless Fallback is true.
+//
+// SandboxExecutor rewrites the command it runs, so it should be the
+// innermost of any composed executors:
+//
+//	client.Executor = run.NewAuditExecutor(path, run.NewSandboxExecutor(nil))
+type SandboxExecutor struct {
+	// Executor runs the sandboxed commands.
+	Executor Executor
+
+	// CPUTime limits the CPU time of the command (rounded up to the second).
+	CPUTime time.Duration
+	// MaxMemory limits the virtual memory of the command, in bytes.
+	MaxMemory int64
+	// MaxOpenFiles limits the number of files the command may open.
+	MaxOpenFiles int
+
+	// Isolate runs the command in new user, mount, and network namespaces.
+	Isolate bool
+	// WorkDir is a directory that remains writable when isolated.
+	// Used as the command working dir if [Cmd.Dir] is not set.
+	WorkDir string
+	// Fallback runs the command without isolation (resource limits only)
+	// when the kernel does not support it, rather than returning an error.
+	Fallback bool
+}
+
+// Supported returns nil if commands can be isolated on this system,
+// otherwise an error wrapping [ErrSandboxUnsupported].
+func (e *SandboxExecutor) Supported() error {
+	return sandboxSupported()
+}
+
+func (e *SandboxExecutor) wrapped() Executor {
+	return e.Executor
+}
+
+func (e *SandboxExecutor) ExitCode(cmd *Cmd) int {
+	return e.Executor.ExitCode(cmd)
+}
+
+func (e *SandboxExecutor) Output(cmd *Cmd) ([]byte, error) {
+	restore, err := e.wrap(cmd)
+	if err != nil {
+		return nil, err
+	}
+	defer restore()
+	return e.Executor.Output(cmd)
+}
+
+func (e *SandboxExecutor) Run(cmd *Cmd) error {
+	restore, err := e.wrap(cmd)
+	if err != nil {
+		return err
+	}
+	defer restore()
+	return e.Executor.Run(cmd)
+}
+
+// wrap rewrites cmd to run inside the sandbox.
+// The returned func restores the original command.
+func (e *SandboxExecutor) wrap(cmd *Cmd) (func(), error) {
+	isolate := e.Isolate
+	if isolate {
+		if err := sandboxSupported(); err != nil {
+			if !e.Fallback {
+				return nil, err
+			}
+			isolate = false
+		}
+	}
+
+	shell, err := exec.LookPath("sh")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSandboxUnsupported, err)
+	}
+
+	path, args, dir, attr := cmd.Path, cmd.Args, cmd.Dir, cmd.SysProcAttr
+	isolateArg, workDir := "", ""
+	if isolate {
+		isolateArg, workDir = "1", e.WorkDir
+		if workDir != "" && cmd.Dir == "" {
+			cmd.Dir = workDir
+		}
+		isolateProcess(cmd)
+	}
+
+	wrapped := []string{"sh", "-c", sandboxScript, "sh", isolateArg, workDir}
+	wrapped = append(wrapped, e.ulimitArgs()...)
+	wrapped = append(wrapped, "--", cmd.Path)
+	wrapped = append(wrapped, cmd.Args[1:]...)
+	cmd.Path = shell
+	cmd.Args = wrapped
+
+	return func() {
+		cmd.Path, cmd.Args, cmd.Dir, cmd.SysProcAttr = path, args, dir, attr
+	}, nil
+}
+
+func (e *SandboxExecutor) ulimitArgs() []string {
+	args := []string{}
+	if e.CPUTime > 0 {
+		secs := int64((e.CPUTime + time.Second - 1) / time.Second)
+		args = append(args, "-t", fmt.Sprint(secs))
+	}
+	if e.MaxMemory > 0 {
+		args = append(args, "-v", fmt.Sprint((e.MaxMemory+1023)/1024))
+	}
+	if e.MaxOpenFiles > 0 {
+		args = append(args, "-n", fmt.Sprint(e.MaxOpenFiles))
+	}
+	return args
+}
+
+// cachedSandboxSupported probes for namespace support once per process.
+func cachedSandboxSupported() error {
+	sandboxOnce.Do(func() {
+		sandboxErr = probeSandbox()
+	})
+	return sandboxErr
+}
@@ -0,0 +1,55 @@
+package run
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// isolateProcess configures cmd to start in new user, mount, and network namespaces.
+// The current user and group are mapped to root inside the user namespace
+// (like `unshare -r`), so the command keeps its capabilities across exec
+// and can set up the mounts. Any existing SysProcAttr is copied, not modified.
+func isolateProcess(cmd *Cmd) {
+	attr := &syscall.SysProcAttr{}
+	if cmd.SysProcAttr != nil {
+		copied := *cmd.SysProcAttr
+		attr = &copied
+	}
+	attr.Cloneflags |= syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWNET
+	attr.UidMappings = []syscall.SysProcIDMap{
+		{ContainerID: 0, HostID: os.Getuid(), Size: 1},
+	}
+	attr.GidMappings = []syscall.SysProcIDMap{
+		{ContainerID: 0, HostID: os.Getgid(), Size: 1},
+	}
+	attr.GidMappingsEnableSetgroups = false
+	cmd.SysProcAttr = attr
+}
+
+// probeSandbox verifies that an isolated process can be started
+// and can remount the filesystems read-only.
+func probeSandbox() error {
+	shell, err := exec.LookPath("sh")
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSandboxUnsupported, err)
+	}
+	stderr := &bytes.Buffer{}
+	cmd := &Cmd{Cmd: exec.Command(shell, "-c", sandboxScript, "sh", "1", "", "--", "true")}
+	cmd.Stderr = stderr
+	isolateProcess(cmd)
+	if err := cmd.Cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return fmt.Errorf(
+			"%w: unable to create user, mount, and network namespaces "+
+				"(are unprivileged user namespaces enabled?): %v",
+			ErrSandboxUnsupported, err,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package run
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSandboxExecutor_Limits(t *testing.T) {
+	executor := NewSandboxExecutor(nil)
+	executor.MaxOpenFiles = 16
+	executor.CPUTime = time.Second
+	client := &Client{Executor: executor}
+
+	buf, err := client.Command("sh", "-c", "ulimit -n; ulimit -t").Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "16\n1\n", string(buf))
+
+	err = client.Command("sh", "-c", "while :; do :; done").Run()
+	assert.Error(t, err)
+}
+
+func TestSandboxExecutor_Isolate(t *testing.T) {
+	executor := NewSandboxExecutor(nil)
+	if err := executor.Supported(); err != nil {
+		t.Skip(err)
+	}
+
+	work := t.TempDir()
+	outside := t.TempDir()
+	executor.Isolate = true
+	executor.WorkDir = work
+	client := &Client{Executor: executor}
+
+	cmd := client.Command("sh", "-c", "pwd; touch created")
+	buf, err := cmd.Output()
+	assert.NoError(t, err)
+	assert.Equal(t, work+"\n", string(buf))
+	assert.FileExists(t, filepath.Join(work, "created"))
+
+	cmd = client.Command("touch", filepath.Join(outside, "created"))
+	assert.Error(t, cmd.Run())
+	assert.NoFileExists(t, filepath.Join(outside, "created"))
+
+	// Only loopback in the new network namespace.
+	buf, err = client.Command("cat", "/proc/net/dev").Output()
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(buf)), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines[2], "lo:")
+
+	// Mounts below the root are read-only too.
+	if _, err := os.Stat("/dev/shm"); err == nil {
+		cmd = client.Command("touch", "/dev/shm/sandbox-test")
+		assert.Error(t, cmd.Run())
+		assert.NoFileExists(t, "/dev/shm/sandbox-test")
+	}
+
+	// The user is mapped to root.
+	buf, err = client.Command("id", "-u").Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "0", strings.TrimSpace(string(buf)))
+
+	// Files created in the work dir are owned by the current user.
+	info, err := os.Stat(filepath.Join(work, "created"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(os.Getuid()), info.Sys().(*syscall.Stat_t).Uid) //nolint:gosec
+}
+
+func TestSandboxExecutor_Isolate_RestoresCmd(t *testing.T) {
+	executor := NewSandboxExecutor(nil)
+	if err := executor.Supported(); err != nil {
+		t.Skip(err)
+	}
+	executor.Isolate = true
+	executor.WorkDir = t.TempDir()
+	client := &Client{Executor: executor}
+
+	attr := &syscall.SysProcAttr{Setpgid: true}
+	cmd := client.Command("true")
+	cmd.SysProcAttr = attr
+	assert.NoError(t, cmd.Run())
+	assert.Equal(t, "", cmd.Dir)
+	assert.Same(t, attr, cmd.SysProcAttr)
+	assert.Equal(t, uintptr(0), attr.Cloneflags)
+}
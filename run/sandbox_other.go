@@ -0,0 +1,14 @@
+//go:build !linux
+
+package run
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func isolateProcess(cmd *Cmd) {}
+
+func probeSandbox() error {
+	return fmt.Errorf("%w: namespaces are not available on %s", ErrSandboxUnsupported, runtime.GOOS)
+}
@@ -0,0 +1,77 @@
+package run
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prashantv/gostub" // spell: disable-line
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSandboxExecutor(t *testing.T) {
+	executor := NewSandboxExecutor(nil)
+	assert.Equal(t, DefaultExecutor, executor.Executor)
+
+	stub := NewStubExecutor()
+	executor = NewSandboxExecutor(stub)
+	assert.Equal(t, stub, executor.Executor)
+}
+
+func TestSandboxExecutor_Run(t *testing.T) {
+	var wrapped []string
+	stub := NewStubExecutor()
+	stub.RegisterStub(
+		MatchArgvPrefix("sh", "-c", sandboxScript),
+		func(cmd *Cmd) ([]byte, []byte, error) {
+			wrapped = cmd.Args
+			return []byte("ok"), nil, nil
+		},
+	)
+	executor := NewSandboxExecutor(stub)
+	executor.CPUTime = 1500 * time.Millisecond
+	executor.MaxMemory = 64 * 1024 * 1024
+	executor.MaxOpenFiles = 32
+	client := &Client{Executor: executor}
+
+	cmd := client.Command("/bin/echo", "hello")
+	buf, err := cmd.Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", string(buf))
+	// Should be restored after running.
+	assert.Equal(t, []string{"/bin/echo", "hello"}, cmd.Args)
+	assert.Equal(t, "/bin/echo", cmd.Path)
+
+	assert.Equal(t, []string{
+		"sh", "-c", sandboxScript, "sh", "", "",
+		"-t", "2", "-v", "65536", "-n", "32",
+		"--", "/bin/echo", "hello",
+	}, wrapped)
+}
+
+func TestSandboxExecutor_Run_WhenUnsupported(t *testing.T) {
+	stubs := gostub.Stub(&sandboxSupported, func() error {
+		return fmt.Errorf("%w: nope", ErrSandboxUnsupported)
+	})
+	defer stubs.Reset()
+
+	stub := NewStubExecutor()
+	stub.RegisterStub(MatchAny, StringResponse(""))
+	executor := NewSandboxExecutor(stub)
+	executor.Isolate = true
+	client := &Client{Executor: executor}
+
+	err := client.Command("/bin/echo").Run()
+	assert.ErrorIs(t, err, ErrSandboxUnsupported)
+	assert.ErrorIs(t, executor.Supported(), ErrSandboxUnsupported)
+	assert.Len(t, stub.Commands, 0)
+
+	executor.Fallback = true
+	executor.WorkDir = "/tmp/work"
+	cmd := client.Command("/bin/echo")
+	err = cmd.Run()
+	assert.NoError(t, err)
+	assert.Len(t, stub.Commands, 1)
+	assert.Nil(t, cmd.SysProcAttr)
+	assert.Equal(t, "", cmd.Dir)
+}
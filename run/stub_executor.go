@@ -1,9 +1,9 @@
 package run
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
+	"os/exec"
 	"sync"
 )
 
@@ -21,6 +21,7 @@ type Stub struct {
 func NewStubExecutor() *StubExecutor {
 	return &StubExecutor{
 		Commands: []*Cmd{},
+		paths:    map[string]string{},
 		stubs:    []*Stub{},
 	}
 }
@@ -30,6 +31,7 @@ type StubExecutor struct {
 	Commands []*Cmd
 
 	mu    sync.Mutex
+	paths map[string]string
 	stubs []*Stub
 }
 
@@ -44,33 +46,32 @@ func (e *StubExecutor) RegisterStub(matcher Matcher, responder Responder) *StubE
 	return e
 }
 
-func (e *StubExecutor) ExitCode(cmd *Cmd) int {
-	return cmd.exitCode
+// RegisterPath registers path as the location of the named executable.
+// See [StubExecutor.LookPath].
+func (e *StubExecutor) RegisterPath(name string, path string) *StubExecutor {
+	e.mu.Lock()
+	e.paths[name] = path
+	e.mu.Unlock()
+	return e
 }
 
-func (e *StubExecutor) Output(cmd *Cmd) ([]byte, error) {
-	if cmd.Stdout != nil {
-		return nil, errors.New("run: Stdout already set")
+// LookPath returns the path registered for file, or an [exec.ErrNotFound]
+// error if there is none.
+func (e *StubExecutor) LookPath(file string) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if path, ok := e.paths[file]; ok {
+		return path, nil
 	}
+	return "", &exec.Error{Name: file, Err: exec.ErrNotFound}
+}
 
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
-
-	cmd.Stdout = &stdout
-
-	captureErr := cmd.Stderr == nil
-	if captureErr {
-		cmd.Stderr = &stderr
-	}
+func (e *StubExecutor) ExitCode(cmd *Cmd) int {
+	return cmd.exitCode
+}
 
-	err := e.Run(cmd)
-	if err != nil && captureErr {
-		var exitErr *ExitError
-		if errors.As(err, &exitErr) {
-			exitErr.Stderr = stderr.Bytes()
-		}
-	}
-	return stdout.Bytes(), err
+func (e *StubExecutor) Output(cmd *Cmd) ([]byte, error) {
+	return captureOutput(cmd, e.Run)
 }
 
 func (e *StubExecutor) Run(cmd *Cmd) error {
@@ -104,13 +105,19 @@ func (e *StubExecutor) Run(cmd *Cmd) error {
 	e.Commands = append(e.Commands, cmd)
 	e.mu.Unlock()
 
-	stdout, _, err := stub.Responder(cmd)
+	stdout, stderr, err := e.respond(cmd, stub.Responder)
 	if cmd.Stdout != nil {
 		_, we := cmd.Stdout.Write(stdout)
 		if we != nil {
 			panic(we)
 		}
 	}
+	if cmd.Stderr != nil {
+		_, we := cmd.Stderr.Write(stderr)
+		if we != nil {
+			panic(we)
+		}
+	}
 
 	var exitErr *ExitError
 	if errors.As(err, &exitErr) {
@@ -122,6 +129,34 @@ func (e *StubExecutor) Run(cmd *Cmd) error {
 	return err
 }
 
+// respond calls responder, honoring any timeout or context on cmd.
+func (e *StubExecutor) respond(cmd *Cmd, responder Responder) ([]byte, []byte, error) {
+	if !cmd.isCancelable() {
+		return responder(cmd)
+	}
+
+	ctx, cancel := cmd.startContext()
+	defer cancel()
+
+	type response struct {
+		stdout []byte
+		stderr []byte
+		err    error
+	}
+	ch := make(chan response, 1)
+	go func() {
+		stdout, stderr, err := responder(cmd)
+		ch <- response{stdout: stdout, stderr: stderr, err: err}
+	}()
+
+	select {
+	case resp := <-ch:
+		return resp.stdout, resp.stderr, resp.err
+	case <-ctx.Done():
+		return nil, nil, cmd.timeoutError(ctx.Err(), false)
+	}
+}
+
 // VerifyStubs fails the test if there are unmatched stubs.
 func (e *StubExecutor) VerifyStubs(test testable) {
 	test.Helper()
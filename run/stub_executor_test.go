@@ -2,10 +2,12 @@ package run
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -95,6 +97,22 @@ func TestStubExecutor_OutputWhenExitError(t *testing.T) {
 	assert.Equal(t, "", string(exitErr.Stderr))
 }
 
+func TestStubExecutor_OutputWhenStderrResponse(t *testing.T) {
+	executor := NewStubExecutor()
+	executor.RegisterStub(
+		MatchString("/bin/date"),
+		StderrResponse([]byte("oops"), 123),
+	)
+
+	cmd := NewClient().Command("/bin/date")
+
+	_, err := executor.Output(cmd)
+	assert.ErrorContains(t, err, "exit status 123")
+
+	exitErr := err.(*ExitError)
+	assert.Equal(t, "oops", string(exitErr.Stderr))
+}
+
 func TestStubExecutor_Run(t *testing.T) {
 	executor := NewStubExecutor()
 	executor.RegisterStub(
@@ -192,3 +210,65 @@ type brokenWriter struct {
 func (w *brokenWriter) Write(p []byte) (int, error) {
 	return 0, w.err
 }
+
+func TestStubExecutor_RunWithTimeout(t *testing.T) {
+	executor := NewStubExecutor()
+	executor.RegisterStub(
+		MatchString("/bin/sleep 10"),
+		HangResponse(),
+	)
+
+	cmd := NewClient().Command("/bin/sleep", "10").WithTimeout(10 * time.Millisecond)
+	err := executor.Run(cmd)
+
+	var timeoutErr *TimeoutError
+	assert.ErrorAs(t, err, &timeoutErr)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, []string{"/bin/sleep", "10"}, timeoutErr.Args)
+	assert.Equal(t, -1, executor.ExitCode(cmd))
+}
+
+func TestStubExecutor_RunWithContext(t *testing.T) {
+	executor := NewStubExecutor()
+	executor.RegisterStub(
+		MatchString("/bin/sleep 10"),
+		HangResponse(),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cmd := NewClient().CommandContext(ctx, "/bin/sleep", "10")
+	err := executor.Run(cmd)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestStubExecutor_RunWithTimeoutWhenCompleted(t *testing.T) {
+	executor := NewStubExecutor()
+	executor.RegisterStub(
+		MatchString("/bin/date"),
+		DelayResponse(time.Millisecond, StringResponse("Sun Nov 13 22:00:00 CST 2022")),
+	)
+
+	cmd := NewClient().Command("/bin/date").WithTimeout(time.Second)
+	buf, err := executor.Output(cmd)
+	assert.NoError(t, err)
+	assert.Equal(t, "Sun Nov 13 22:00:00 CST 2022", string(buf))
+	assert.Equal(t, 0, executor.ExitCode(cmd))
+}
+
+func TestStubExecutor_RunWithStderr(t *testing.T) {
+	executor := NewStubExecutor()
+	executor.RegisterStub(
+		MatchString("/bin/date"),
+		StderrResponse([]byte("oops"), 1),
+	)
+
+	stderr := &bytes.Buffer{}
+	cmd := NewClient().Command("/bin/date")
+	cmd.Stderr = stderr
+
+	err := executor.Run(cmd)
+	assert.ErrorContains(t, err, "exit status 1")
+	assert.Equal(t, "oops", stderr.String())
+}
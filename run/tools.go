@@ -0,0 +1,292 @@
+package run
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3" // cspell: disable-line
+)
+
+var (
+	// ErrToolVersion is returned when a tool does not satisfy its version constraint.
+	ErrToolVersion = errors.New("version does not satisfy constraint")
+
+	defaultVersionArgs    = []string{"--version"}
+	defaultVersionPattern = `\d+\.\d+(?:\.\d+)?`
+)
+
+// Tool describes an external executable that the app depends on.
+type Tool struct {
+	// Name is the name of the executable (as looked up in PATH).
+	Name string
+	// Constraint is an optional semver constraint (i.e. ">= 2.30, < 3").
+	Constraint string
+	// VersionArgs are the args used to print the tool version.
+	// Defaults to `--version`.
+	VersionArgs []string
+	// VersionPattern is a regular expression used to extract the version
+	// from the output of VersionArgs. If the pattern contains a capture group,
+	// the first group is used. Defaults to the first `x.y.z` style version.
+	VersionPattern string
+	// InstallHint tells the user how to install the tool
+	// (i.e. "brew install git" or a URL).
+	InstallHint string
+}
+
+// ToolStatus is the result of checking for a Tool.
+type ToolStatus struct {
+	Tool Tool
+	// Path is the resolved path to the executable.
+	Path string
+	// Version is the detected version.
+	// Only set when the tool has a version constraint.
+	Version string
+	// Err is set if the tool is missing or does not satisfy the constraint.
+	Err error
+}
+
+// ToolError is returned when a required tool is missing or too old.
+type ToolError struct {
+	Tool    Tool
+	Version string
+	Err     error
+}
+
+func (e *ToolError) Unwrap() error {
+	return e.Err
+}
+
+// Error returns the error message.
+func (e *ToolError) Error() string {
+	var msg string
+	switch {
+	case errors.Is(e.Err, exec.ErrNotFound):
+		msg = fmt.Sprintf("%s: not found in PATH", e.Tool.Name)
+	case errors.Is(e.Err, ErrToolVersion):
+		msg = fmt.Sprintf("%s: version %s does not satisfy %s", e.Tool.Name, e.Version, e.Tool.Constraint)
+	default:
+		msg = fmt.Sprintf("%s: %v", e.Tool.Name, e.Err)
+	}
+	if e.Tool.InstallHint != "" {
+		msg += fmt.Sprintf(" (install: %s)", e.Tool.InstallHint)
+	}
+	return msg
+}
+
+// ToolsError aggregates the errors for all missing or outdated tools.
+type ToolsError struct {
+	Errors []*ToolError
+}
+
+func (e *ToolsError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Error returns the error message.
+func (e *ToolsError) Error() string {
+	lines := []string{e.header()}
+	for _, err := range e.Errors {
+		lines = append(lines, "  - "+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// header returns the first line of the error message,
+// worded by the kind of failures.
+func (e *ToolsError) header() string {
+	missing, outdated := 0, 0
+	for _, err := range e.Errors {
+		switch {
+		case errors.Is(err, exec.ErrNotFound):
+			missing++
+		case errors.Is(err, ErrToolVersion):
+			outdated++
+		}
+	}
+	switch len(e.Errors) {
+	case missing:
+		return "missing required tools:"
+	case outdated:
+		return "outdated required tools:"
+	default:
+		return "unsatisfied tool requirements:"
+	}
+}
+
+// LookPath searches for the named executable in PATH.
+// When stubbing, paths must be registered with [Client.RegisterPath].
+// Executors wrapping a [StubExecutor] (i.e. [AuditExecutor]) are unwrapped.
+func (c *Client) LookPath(file string) (string, error) {
+	executor := c.Executor
+	for executor != nil {
+		if pl, ok := executor.(pathLooker); ok {
+			return pl.LookPath(file)
+		}
+		w, ok := executor.(wrappingExecutor)
+		if !ok {
+			break
+		}
+		executor = w.wrapped()
+	}
+	return exec.LookPath(file)
+}
+
+// RegisterPath registers the path for the named executable when stubbing.
+func (c *Client) RegisterPath(name string, path string) *Client {
+	if !c.IsStubbed() {
+		panic("must enable stubbing before registering paths")
+	}
+	executor := c.Executor.(*StubExecutor)
+	executor.RegisterPath(name, path)
+	return c
+}
+
+// CheckTool looks for tool in PATH and verifies its version constraint (if any).
+// Results are cached for the lifetime of the client.
+func (c *Client) CheckTool(tool Tool) *ToolStatus {
+	status := &ToolStatus{
+		Tool: tool,
+	}
+
+	info := c.toolInfo(tool)
+	status.Path = info.path
+	status.Version = info.version
+	if info.err != nil {
+		status.Err = &ToolError{Tool: tool, Version: info.version, Err: info.err}
+		return status
+	}
+
+	if tool.Constraint != "" {
+		if err := checkVersion(tool.Constraint, info.version); err != nil {
+			status.Err = &ToolError{Tool: tool, Version: info.version, Err: err}
+		}
+	}
+	return status
+}
+
+// RequireTools checks each of tools and returns a [ToolsError]
+// listing all the tools that are missing or too old.
+func (c *Client) RequireTools(tools ...Tool) error {
+	agg := &ToolsError{}
+	for _, tool := range tools {
+		status := c.CheckTool(tool)
+		var toolErr *ToolError
+		if errors.As(status.Err, &toolErr) {
+			agg.Errors = append(agg.Errors, toolErr)
+		}
+	}
+	if len(agg.Errors) > 0 {
+		return agg
+	}
+	return nil
+}
+
+type toolInfo struct {
+	path    string
+	version string
+	err     error
+
+	// ready is closed once the fields above are set.
+	ready chan struct{}
+}
+
+// toolInfo returns the (cached) path and version for tool.
+func (c *Client) toolInfo(tool Tool) *toolInfo {
+	args := tool.VersionArgs
+	if len(args) == 0 {
+		args = defaultVersionArgs
+	}
+	pattern := tool.VersionPattern
+	if pattern == "" {
+		pattern = defaultVersionPattern
+	}
+	needsVersion := tool.Constraint != ""
+	key := strings.Join(append([]string{tool.Name, strconv.FormatBool(needsVersion), pattern}, args...), "\x00")
+
+	// Only hold the lock for the map access so that a slow version
+	// command doesn't block lookups of other tools. Concurrent lookups
+	// of the same tool wait for the first one to finish.
+	c.mu.Lock()
+	if c.tools == nil {
+		c.tools = map[string]*toolInfo{}
+	}
+	info, ok := c.tools[key]
+	if !ok {
+		info = &toolInfo{ready: make(chan struct{})}
+		c.tools[key] = info
+	}
+	c.mu.Unlock()
+
+	if ok {
+		<-info.ready
+		return info
+	}
+	defer close(info.ready)
+
+	info.path, info.err = c.LookPath(tool.Name)
+	if info.err != nil || !needsVersion {
+		return info
+	}
+	info.version, info.err = c.toolVersion(tool.Name, args, pattern)
+	return info
+}
+
+// toolVersion runs the tool with args and extracts the version using pattern.
+func (c *Client) toolVersion(name string, args []string, pattern string) (string, error) {
+	r, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid version pattern: %w", err)
+	}
+
+	// Some tools print their version to stderr.
+	var buf bytes.Buffer
+	cmd := c.Command(name, args...)
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("unable to determine version: %w", err)
+	}
+
+	matches := r.FindStringSubmatch(buf.String())
+	switch {
+	case len(matches) > 1:
+		return matches[1], nil
+	case len(matches) == 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("unable to determine version from: %q", strings.TrimSpace(buf.String()))
+	}
+}
+
+func checkVersion(constraint string, version string) error {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return fmt.Errorf("invalid version constraint: %w", err)
+	}
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return fmt.Errorf("invalid version %q: %w", version, err)
+	}
+	if !c.Check(v) {
+		return ErrToolVersion
+	}
+	return nil
+}
+
+type pathLooker interface {
+	LookPath(file string) (string, error)
+}
+
+// wrappingExecutor is implemented by executors that wrap another executor.
+type wrappingExecutor interface {
+	wrapped() Executor
+}
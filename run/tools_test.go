@@ -0,0 +1,200 @@
+package run
+
+import (
+	"errors"
+	"os/exec"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_LookPath(t *testing.T) {
+	client := NewClient()
+	path, err := client.LookPath("sh")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, path)
+
+	client = NewClient().WithStubbing()
+	_, err = client.LookPath("sh")
+	assert.ErrorIs(t, err, exec.ErrNotFound)
+
+	client.RegisterPath("sh", "/stubbed/sh")
+	path, err = client.LookPath("sh")
+	assert.NoError(t, err)
+	assert.Equal(t, "/stubbed/sh", path)
+
+	assert.PanicsWithValue(t, "must enable stubbing before registering paths", func() {
+		NewClient().RegisterPath("sh", "/stubbed/sh")
+	})
+}
+
+func TestClient_CheckTool(t *testing.T) {
+	client := NewClient().WithStubbing()
+	defer client.VerifyStubs(t)
+
+	client.RegisterPath("git", "/usr/bin/git")
+	client.RegisterStub(
+		MatchArgv("git", "--version"),
+		StringResponse("git version 2.39.3 (Apple Git-145)\n"),
+	)
+
+	status := client.CheckTool(Tool{Name: "git", Constraint: ">= 2.30"})
+	assert.NoError(t, status.Err)
+	assert.Equal(t, "/usr/bin/git", status.Path)
+	assert.Equal(t, "2.39.3", status.Version)
+
+	// Results should be cached (the stub only matches once).
+	status = client.CheckTool(Tool{Name: "git", Constraint: ">= 2.40"})
+	assert.ErrorIs(t, status.Err, ErrToolVersion)
+	assert.EqualError(t, status.Err, "git: version 2.39.3 does not satisfy >= 2.40")
+
+	// No constraint means no version check.
+	status = client.CheckTool(Tool{Name: "git"})
+	assert.NoError(t, status.Err)
+}
+
+func TestClient_CheckTool_VersionPattern(t *testing.T) {
+	client := NewClient().WithStubbing()
+	defer client.VerifyStubs(t)
+
+	client.RegisterPath("java", "/usr/bin/java")
+	client.RegisterStub(
+		MatchArgv("java", "-version"),
+		MuxResponse(nil, []byte(`openjdk version "17.0.2" 2022-01-18`), 0),
+	)
+
+	status := client.CheckTool(Tool{
+		Name:           "java",
+		Constraint:     "^17",
+		VersionArgs:    []string{"-version"},
+		VersionPattern: `version "([^"]+)"`,
+	})
+	assert.NoError(t, status.Err)
+	assert.Equal(t, "17.0.2", status.Version)
+}
+
+func TestClient_CheckTool_Errors(t *testing.T) {
+	tests := []struct {
+		desc     string
+		tool     Tool
+		stub     Responder
+		expected string
+	}{
+		{
+			desc:     "command error",
+			tool:     Tool{Name: "helm", Constraint: ">= 3"},
+			stub:     ErrorResponse(NewExitError(1)),
+			expected: "helm: unable to determine version: helm --version: exit status 1",
+		},
+		{
+			desc:     "no version in output",
+			tool:     Tool{Name: "helm", Constraint: ">= 3"},
+			stub:     StringResponse("unknown\n"),
+			expected: `helm: unable to determine version from: "unknown"`,
+		},
+		{
+			desc:     "invalid pattern",
+			tool:     Tool{Name: "helm", Constraint: ">= 3", VersionPattern: "("},
+			expected: "helm: invalid version pattern: error parsing regexp",
+		},
+		{
+			desc:     "invalid constraint",
+			tool:     Tool{Name: "helm", Constraint: "!!!"},
+			stub:     StringResponse("v3.1.0"),
+			expected: "helm: invalid version constraint",
+		},
+		{
+			desc:     "invalid version",
+			tool:     Tool{Name: "helm", Constraint: ">= 3", VersionPattern: `(\w+)`},
+			stub:     StringResponse("nope"),
+			expected: `helm: invalid version "nope"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			client := NewClient().WithStubbing()
+			defer client.VerifyStubs(t)
+
+			client.RegisterPath("helm", "/usr/bin/helm")
+			if tt.stub != nil {
+				client.RegisterStub(MatchName("helm"), tt.stub)
+			}
+
+			status := client.CheckTool(tt.tool)
+			assert.ErrorContains(t, status.Err, tt.expected)
+		})
+	}
+}
+
+func TestClient_RequireTools(t *testing.T) {
+	client := NewClient().WithStubbing()
+	defer client.VerifyStubs(t)
+
+	client.RegisterPath("git", "/usr/bin/git")
+	client.RegisterPath("helm", "/usr/bin/helm")
+	client.RegisterStub(MatchArgv("git", "--version"), StringResponse("git version 2.39.3"))
+	client.RegisterStub(MatchArgv("helm", "version"), StringResponse("v3.1.0+g1234"))
+
+	err := client.RequireTools(
+		Tool{Name: "git", Constraint: ">= 2.30"},
+		Tool{Name: "helm", Constraint: ">= 3.5", VersionArgs: []string{"version"}, InstallHint: "brew install helm"},
+		Tool{Name: "docker", InstallHint: "https://docs.docker.com/get-docker/"},
+	)
+	assert.EqualError(t, err, "unsatisfied tool requirements:\n"+
+		"  - helm: version 3.1.0 does not satisfy >= 3.5 (install: brew install helm)\n"+
+		"  - docker: not found in PATH (install: https://docs.docker.com/get-docker/)",
+	)
+	assert.ErrorIs(t, err, exec.ErrNotFound)
+	assert.ErrorIs(t, err, ErrToolVersion)
+
+	var toolsErr *ToolsError
+	assert.True(t, errors.As(err, &toolsErr))
+	assert.Len(t, toolsErr.Errors, 2)
+
+	assert.NoError(t, client.RequireTools(Tool{Name: "git", Constraint: ">= 2.30"}))
+
+	err = client.RequireTools(Tool{Name: "docker"}, Tool{Name: "kubectl"})
+	assert.EqualError(t, err, "missing required tools:\n"+
+		"  - docker: not found in PATH\n"+
+		"  - kubectl: not found in PATH",
+	)
+
+	err = client.RequireTools(Tool{Name: "git", Constraint: ">= 3"})
+	assert.EqualError(t, err, "outdated required tools:\n"+
+		"  - git: version 2.39.3 does not satisfy >= 3",
+	)
+}
+
+func TestClient_LookPath_WhenWrapped(t *testing.T) {
+	stub := NewStubExecutor()
+	stub.RegisterPath("sh", "/stubbed/sh")
+	dryRun := NewDryRunExecutor(nil)
+	dryRun.Executor = stub
+	client := &Client{Executor: NewAuditExecutor(t.TempDir()+"/audit.log", dryRun)}
+
+	path, err := client.LookPath("sh")
+	assert.NoError(t, err)
+	assert.Equal(t, "/stubbed/sh", path)
+}
+
+func TestClient_CheckTool_Concurrent(t *testing.T) {
+	client := NewClient().WithStubbing()
+	defer client.VerifyStubs(t)
+
+	client.RegisterPath("git", "/usr/bin/git")
+	client.RegisterStub(MatchArgv("git", "--version"), StringResponse("git version 2.39.3"))
+
+	// The version command should only run once (the stub only matches once).
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := client.CheckTool(Tool{Name: "git", Constraint: ">= 2.30"})
+			assert.NoError(t, status.Err)
+			assert.Equal(t, "2.39.3", status.Version)
+		}()
+	}
+	wg.Wait()
+}
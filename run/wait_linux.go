@@ -0,0 +1,22 @@
+//go:build linux
+
+package run
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// awaitExit blocks until p exits, without reaping it.
+// Returns false if p could not be waited for.
+func awaitExit(p *os.Process) bool {
+	for {
+		info := unix.Siginfo{}
+		err := unix.Waitid(unix.P_PID, p.Pid, &info, unix.WEXITED|unix.WNOWAIT, nil)
+		if !errors.Is(err, unix.EINTR) {
+			return err == nil
+		}
+	}
+}
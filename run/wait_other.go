@@ -0,0 +1,10 @@
+//go:build !linux
+
+package run
+
+import "os"
+
+// awaitExit is not supported on this platform.
+func awaitExit(p *os.Process) bool {
+	return false
+}
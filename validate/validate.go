@@ -55,6 +55,12 @@ func RegisterValidation(tag string, translation string, fn validator.Func) {
 	}
 }
 
+// RegisterCustomTypeFunc registers fn to convert values of the given types
+// before they are validated (i.e. to validate a struct type as a string).
+func RegisterCustomTypeFunc(fn validator.CustomTypeFunc, types ...any) {
+	validate.RegisterCustomTypeFunc(fn, types...)
+}
+
 // Struct validates a struct using tag style validation.
 func Struct(data any) error {
 	return translateErr("", validate.Struct(data))
@@ -1,6 +1,7 @@
 package validate
 
 import (
+	"reflect"
 	"testing"
 
 	validator "github.com/go-playground/validator/v10"
@@ -34,6 +35,22 @@ func TestRegisterTranslation(t *testing.T) {
 	})
 }
 
+type wrapped struct {
+	value string
+}
+
+func TestRegisterCustomTypeFunc(t *testing.T) {
+	type gadget struct {
+		Name wrapped `validate:"required"`
+	}
+	RegisterCustomTypeFunc(func(v reflect.Value) any {
+		return v.Interface().(wrapped).value
+	}, wrapped{})
+
+	assert.NoError(t, Struct(&gadget{Name: wrapped{value: "untitled"}}))
+	assert.ErrorContains(t, Struct(&gadget{}), "Name is a required field")
+}
+
 func TestStruct(t *testing.T) {
 	assert.NoError(t, Struct(&widget{
 		Name:   "untitled",